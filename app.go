@@ -2,16 +2,37 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/nexidian/gocliselect"
 )
 
+// subcommands of sheet command, sheet named like one could not be selected
+var reservedSheetNames = []string{"list", "rename", "delete", "archive", "unarchive"}
+
+// fails if name is reserved for sheet subcommand
+func checkSheetName(name string) error {
+	for _, reserved := range reservedSheetNames {
+		if name == reserved {
+			return fmt.Errorf("Sheet name %s is reserved for 'sheet %s' command", name, name)
+		}
+	}
+	return nil
+}
+
 func (a *App) ChangeSheet(name string) error {
+	if err := a.checkNotArchived(name); err != nil {
+		return err
+	}
+
 	if a.repo.CheckSheetExists(name) {
 		if err := a.repo.SetActiveSheet(name); err != nil {
 			return err
@@ -19,6 +40,9 @@ func (a *App) ChangeSheet(name string) error {
 
 		fmt.Printf("Changed sheet to: %s\n", name)
 	} else {
+		if err := checkSheetName(name); err != nil {
+			return err
+		}
 		if err := a.repo.CreateSheet(name); err != nil {
 			return err
 		}
@@ -32,172 +56,1242 @@ func (a *App) ChangeSheet(name string) error {
 	return nil
 }
 
-func (a *App) StartTracking(note string) error {
-	id, err := a.repo.GetActiveSheetID()
+// prints sheets with number of entries, total time and last use,
+// active sheet is marked with "*", archived sheets are listed only with all
+func (a *App) ListSheets(all bool) error {
+	sheets, err := a.repo.GetSheetsWithStats()
 	if err != nil {
 		return err
 	}
-	if id == 0 {
-		return fmt.Errorf("No active sheet selected, use 'sheet' command to select or create new one")
+
+	headers := []string{"", "Sheet", "Entries", "Total", "Last used"}
+
+	var rows [][]string
+	for _, sheet := range sheets {
+		if sheet.Archived && !all {
+			continue
+		}
+
+		marker := ""
+		if sheet.Active {
+			marker = "*"
+		}
+
+		name := sheet.Name
+		if sheet.Archived {
+			name += " (archived)"
+		}
+
+		lastUsed := "-"
+		if !sheet.LastUsed.IsZero() {
+			lastUsed = sheet.LastUsed.Format("Jan 02, 2006")
+		}
+
+		rows = append(rows, []string{
+			marker,
+			name,
+			strconv.Itoa(sheet.EntryCount),
+			FormatDuration(sheet.Total),
+			lastUsed,
+		})
+	}
+
+	if len(rows) == 0 {
+		fmt.Println("No sheets, use 'sheet' command to create one")
+		return nil
+	}
+
+	PrintTable(os.Stdout, headers, rows, nil)
+	return nil
+}
+
+func (a *App) RenameSheet(oldName string, newName string) error {
+	if err := checkSheetName(newName); err != nil {
+		return err
+	}
+	if a.repo.CheckSheetExists(newName) {
+		return fmt.Errorf("Sheet %s already exists", newName)
 	}
 
-	startTime := time.Now()
-	if err := a.repo.CreateEntry(id, startTime, note); err != nil {
+	if err := a.repo.RenameSheet(oldName, newName); err != nil {
 		return err
 	}
 
-	fmt.Println("Started tracking time...")
+	fmt.Printf("Renamed sheet %s to %s\n", oldName, newName)
 	return nil
 }
 
-func (a *App) StopTracking(note string) error {
-	endTime := time.Now()
+// deletes sheet with its entries, or moves entries to reassignTo sheet,
+// asks for confirmation unless yes is set
+func (a *App) DeleteSheet(name string, reassignTo string, yes bool) error {
+	sheetID, err := a.repo.GetSheetIdByName(name)
+	if err != nil {
+		return err
+	}
 
-	if a.repo.HasActiveEntryNote() == false && note == "" {
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Enter a note (press Enter to skip): ")
-		inputNote, _ := reader.ReadString('\n')
-		note = strings.TrimSpace(inputNote)
+	count, err := a.repo.CountSheetEntries(sheetID)
+	if err != nil {
+		return err
+	}
+
+	if reassignTo != "" && !a.repo.CheckSheetExists(reassignTo) {
+		if err := checkSheetName(reassignTo); err != nil {
+			return err
+		}
 	}
 
-	err := a.repo.UpdateEntry(endTime, note)
+	// running entry is never deleted, it can only be moved with its sheet
+	running, err := a.repo.GetRunningSheetEntry(sheetID)
 	if err != nil {
 		return err
 	}
+	if running != nil {
+		if reassignTo == "" {
+			return fmt.Errorf("Sheet %s has running entry, stop it first or move entries with --reassign", name)
+		}
+		if err := a.checkNoRunningEntry(reassignTo); err != nil {
+			return err
+		}
+	}
 
-	fmt.Println("Tracking stopped!")
+	activeID, err := a.repo.GetActiveSheetID()
+	if err != nil {
+		return err
+	}
+	active := activeID == sheetID
+
+	if !yes {
+		kind := "sheet"
+		if active {
+			kind = "active sheet"
+		}
+		question := fmt.Sprintf("Delete %s %s and its %d entries?", kind, name, count)
+		if reassignTo != "" {
+			question = fmt.Sprintf("Delete %s %s and move its %d entries to %s?", kind, name, count, reassignTo)
+		}
+
+		if !confirm(question) {
+			fmt.Println("Aborted.")
+			return nil
+		}
+	}
+
+	if _, err := a.repo.AutoBackup("sheet-delete"); err != nil {
+		return fmt.Errorf("failed to back up database before deleting sheet: %w", err)
+	}
+
+	if err := a.repo.DeleteSheet(sheetID, reassignTo); err != nil {
+		return err
+	}
+
+	if reassignTo != "" {
+		fmt.Printf("Deleted sheet %s, %d entries moved to %s\n", name, count, reassignTo)
+	} else {
+		fmt.Printf("Deleted sheet %s and %d entries\n", name, count)
+	}
+
+	// active sheet is taken over by sheet receiving its entries
+	if active && reassignTo != "" {
+		fmt.Printf("Changed sheet to: %s\n", reassignTo)
+	} else if active {
+		fmt.Println("No sheet is active now, use 'sheet' command to select or create one")
+	}
 	return nil
 }
 
-func (a *App) Display(displayType string) error {
-	now := time.Now()
-	var startTime, endTime time.Time
+// with timer per sheet, fails if sheet (when it exists) has running entry
+func (a *App) checkNoRunningEntry(name string) error {
+	if !a.config.TimerPerSheet || !a.repo.CheckSheetExists(name) {
+		return nil
+	}
 
-	switch displayType {
-	case "day":
-		startTime = now.Truncate(24 * time.Hour)
-		endTime = startTime.Add(24 * time.Hour)
-	case "week":
-		startOfWeek := now.AddDate(0, 0, -int(now.Weekday())+1)
-		if now.Weekday() == time.Sunday {
-			startOfWeek = startOfWeek.AddDate(0, 0, -6)
-		}
-		startTime = startOfWeek
-		endTime = startOfWeek.AddDate(0, 0, 7)
-	case "month":
-		startTime = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		endTime = startTime.AddDate(0, 1, 0)
-	case "year":
-		startTime = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
-		endTime = startTime.AddDate(1, 0, 0)
-	default:
-		return fmt.Errorf("Invalid display mode: %s", displayType)
+	sheetID, err := a.repo.GetSheetIdByName(name)
+	if err != nil {
+		return err
+	}
+	running, err := a.repo.GetRunningSheetEntry(sheetID)
+	if err != nil {
+		return err
 	}
+	if running != nil {
+		return fmt.Errorf("Entry on sheet %s is already running", name)
+	}
+	return nil
+}
 
-	sheets, err := a.repo.GetSheetsWithEntries(startTime, endTime)
+// hides sheet from completion and display without deleting its entries
+func (a *App) ArchiveSheet(name string, archived bool) error {
+	if archived {
+		sheetID, err := a.repo.GetSheetIdByName(name)
+		if err != nil {
+			return err
+		}
+
+		running, err := a.repo.GetRunningSheetEntry(sheetID)
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			return fmt.Errorf("Sheet %s has running entry, stop it first", name)
+		}
+	}
+
+	if err := a.repo.SetSheetArchived(name, archived); err != nil {
+		return err
+	}
+
+	if archived {
+		fmt.Printf("Archived sheet %s\n", name)
+	} else {
+		fmt.Printf("Unarchived sheet %s\n", name)
+	}
+	return nil
+}
+
+// archived sheet can't be activated or tracked until it is unarchived
+func (a *App) checkNotArchived(name string) error {
+	archived, err := a.repo.IsSheetArchived(name)
 	if err != nil {
-		fmt.Errorf("a %v", err)
+		return err
 	}
-	for _, sheet := range sheets {
-		fmt.Printf("Sheet - %s\n", sheet.Name)
+	if archived {
+		return fmt.Errorf("Sheet %s is archived, use 'sheet unarchive %s' first", name, name)
+	}
+	return nil
+}
 
-		headers := []string{"Day", "Start", "End", "Duration", "Notes"}
+// starts entry on active sheet, tags are taken from hashtags in note
+// and given tags
+func (a *App) StartTracking(note string, at string, tags []string) error {
+	tags, err := EntryTags(note, tags)
+	if err != nil {
+		return err
+	}
 
-		var rows [][]string
-		totalDuration := time.Duration(0)
+	id, err := a.repo.GetActiveSheetID()
+	if err != nil {
+		return err
+	}
+	if id == 0 {
+		return fmt.Errorf("No active sheet selected, use 'sheet' command to select or create new one")
+	}
 
-		var lastDay string
-		for _, entry := range sheet.Entries {
-			day := entry.StartTime.Format("Jan 02, 2006")
-			startTime := entry.StartTime.Format("15:04:05")
-			endTime := entry.EndTime.Format("15:04:05")
-			duration := entry.EndTime.Sub(entry.StartTime)
-			totalDuration += duration
-
-			if day != lastDay {
-				row := []string{
-					day,
-					startTime,
-					endTime,
-					FormatDuration(duration),
-					entry.Note,
-				}
-
-				rows = append(rows, row)
-				lastDay = day
-			} else {
-				row := []string{
-					"",
-					startTime,
-					endTime,
-					FormatDuration(duration),
-					entry.Note,
-				}
-
-				rows = append(rows, row)
-			}
+	startTime := a.timeParser.Current()
+	if at != "" {
+		startTime, err = a.timeParser.Parse(at)
+		if err != nil {
+			return err
 		}
+	}
+
+	running, runningSheet, err := a.runningBeforeStart(id, startTime)
+	if err != nil {
+		return err
+	}
 
-		footers := []string{"", "", "Total:", FormatDuration(totalDuration), ""}
-		PrintTable(headers, rows, footers)
+	// running entry is stopped only if new one is started
+	if err := a.repo.StartEntry(running, id, startTime, note, tags); err != nil {
+		return err
+	}
 
-		fmt.Println()
-		fmt.Println()
+	if running != nil {
+		fmt.Printf("Stopped running entry on sheet %s.\n", runningSheet)
 	}
 
+	fmt.Printf("Started tracking time at %s...\n", startTime.Format("15:04:05"))
 	return nil
 }
 
-func (a *App) Import(url string) (string, error) {
-	apiClient := NewAPIClient(url)
+// returns entry which has to be stopped before starting new one on sheet
+// and name of its sheet, fails if start_when_running policy refuses start
+func (a *App) runningBeforeStart(sheetID int64, startTime time.Time) (*Entry, string, error) {
+	// with timer per sheet only entry on the same sheet is in the way
+	var running *Entry
+	var err error
+	if a.config.TimerPerSheet {
+		running, err = a.repo.GetRunningSheetEntry(sheetID)
+	} else {
+		running, err = a.repo.GetRunningEntry()
+	}
+	if err != nil || running == nil {
+		return nil, "", err
+	}
 
-	var IDs []int64
+	sheetName, err := a.repo.GetSheetNameByID(running.SheetID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if a.config.StartWhenRunning != "stop" {
+		return nil, "", fmt.Errorf("Entry on sheet %s is already running since %s, stop it first", sheetName, running.StartTime.Format("2006-01-02 15:04:05"))
+	}
+	if startTime.Before(running.StartTime) {
+		return nil, "", fmt.Errorf("Start time %s is before start of running entry on sheet %s", startTime.Format("2006-01-02 15:04:05"), sheetName)
+	}
+
+	return running, sheetName, nil
+}
 
-	unimportedEntries, err := apiClient.GetUnimportedEntries()
+// adds finished entry after the fact, end can be time or duration from
+// start ("2h30m", "+45m"), entries overlapping on same sheet are reported
+func (a *App) AddEntry(start string, end string, note string, sheet string, tags []string) error {
+	tags, err := EntryTags(note, tags)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	for i, entry := range unimportedEntries {
-		var endTime sql.NullTime
-		if entry.EndTime.Valid {
-			endTime = sql.NullTime{
-				Time:  entry.EndTime.Time,
-				Valid: true,
-			}
-		} else {
-			endTime = sql.NullTime{Valid: false}
+	startTime, err := a.timeParser.Parse(start)
+	if err != nil {
+		return err
+	}
+
+	endTime, err := a.timeParser.ParseEnd(startTime, end)
+	if err != nil {
+		return err
+	}
+
+	if !endTime.After(startTime) {
+		return fmt.Errorf("End time %s must be after start time %s", endTime.Format("2006-01-02 15:04:05"), startTime.Format("2006-01-02 15:04:05"))
+	}
+
+	var sheetID int64
+	if sheet != "" {
+		if err := a.checkNotArchived(sheet); err != nil {
+			return err
+		}
+		sheetID, err = a.repo.GetSheetIdByName(sheet)
+	} else {
+		sheetID, err = a.repo.GetActiveSheetID()
+		if err == nil && sheetID == 0 {
+			err = fmt.Errorf("No active sheet selected, use --sheet or 'sheet' command")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if sheet == "" {
+		sheet, err = a.repo.GetSheetNameByID(sheetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	overlapping, err := a.repo.GetOverlappingEntries(sheetID, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	for _, entry := range overlapping {
+		end := "running"
+		if !entry.EndTime.IsZero() {
+			end = entry.EndTime.Format("15:04:05")
 		}
+		fmt.Printf("Warning: overlaps entry %d (%s %s - %s) %s\n", entry.ID, entry.StartTime.Format("Jan 02"), entry.StartTime.Format("15:04:05"), end, entry.Note)
+	}
 
-		sheets, _ := a.repo.GetAllSheets()
+	if err := a.repo.CreateFullEntry(sheet, startTime, sql.NullTime{Time: endTime, Valid: true}, note, tags); err != nil {
+		return err
+	}
 
-		menu := gocliselect.NewMenu(fmt.Sprintf("Start time: %s\nEnd time: %s\nNote: %s", entry.StartTime.Format("2006-01-02 15:04:05"), entry.EndTime.Time.Format("2006-01-02 15:04:05"), entry.Note))
+	fmt.Printf("Added entry on sheet %s: %s - %s (%s)\n", sheet, startTime.Format("Jan 02 15:04:05"), endTime.Format("15:04:05"), FormatDuration(endTime.Sub(startTime)))
+	return nil
+}
 
-		for _, sheet := range sheets {
-			menu.AddItem(sheet, sheet)
+// stops running entry and starts new one on sheet without gap or overlap
+func (a *App) Switch(sheetName string, note string, at string) error {
+	var err error
+	switchTime := a.timeParser.Current()
+	if at != "" {
+		switchTime, err = a.timeParser.Parse(at)
+		if err != nil {
+			return err
 		}
+	}
 
-		if i == len(unimportedEntries)-1 {
-			menu.EnableSkip("skip and exit")
-		} else {
-			menu.EnableSkip("skip and go to next")
+	if err := a.checkNotArchived(sheetName); err != nil {
+		return err
+	}
+	if !a.repo.CheckSheetExists(sheetName) {
+		if err := checkSheetName(sheetName); err != nil {
+			return err
 		}
+	}
+
+	running, err := a.switchedEntry(sheetName)
+	if err != nil {
+		return err
+	}
+
+	tags, err := EntryTags(note, nil)
+	if err != nil {
+		return err
+	}
 
-		sheetName, _ := menu.Display()
+	if running != nil && switchTime.Before(running.StartTime) {
+		return fmt.Errorf("Switch time %s is before start time %s", switchTime.Format("2006-01-02 15:04:05"), running.StartTime.Format("2006-01-02 15:04:05"))
+	}
 
-		if sheetName == nil {
-			continue
+	if err := a.repo.SwitchEntry(running, sheetName, switchTime, note, tags); err != nil {
+		return err
+	}
+
+	if running != nil {
+		fmt.Printf("Stopped running entry and switched to sheet %s at %s\n", sheetName, switchTime.Format("15:04:05"))
+	} else {
+		fmt.Printf("Switched to sheet %s and started tracking at %s\n", sheetName, switchTime.Format("15:04:05"))
+	}
+	return nil
+}
+
+// returns running entry which switch stops, with timer per sheet it is
+// entry of active sheet and target sheet must not have running entry
+func (a *App) switchedEntry(sheetName string) (*Entry, error) {
+	if !a.config.TimerPerSheet {
+		return a.repo.GetRunningEntry()
+	}
+
+	var running *Entry
+	activeID, err := a.repo.GetActiveSheetID()
+	if err != nil {
+		return nil, err
+	}
+	if activeID != 0 {
+		running, err = a.repo.GetRunningSheetEntry(activeID)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		err = a.repo.CreateFullEntry(sheetName.(string), entry.StartTime, endTime, entry.Note)
+	if a.repo.CheckSheetExists(sheetName) {
+		targetID, err := a.repo.GetSheetIdByName(sheetName)
 		if err != nil {
-			return "", err
+			return nil, err
+		}
+		if targetID != activeID {
+			targetRunning, err := a.repo.GetRunningSheetEntry(targetID)
+			if err != nil {
+				return nil, err
+			}
+			if targetRunning != nil {
+				return nil, fmt.Errorf("Entry on sheet %s is already running", sheetName)
+			}
+		}
+	}
+
+	return running, nil
+}
+
+// stops running entry of sheet (active sheet if empty)
+func (a *App) StopTracking(note string, at string, sheet string) error {
+	var sheetID int64
+	var err error
+
+	if sheet != "" {
+		sheetID, err = a.repo.GetSheetIdByName(sheet)
+	} else {
+		sheetID, err = a.repo.GetActiveSheetID()
+	}
+	if err != nil {
+		return err
+	}
+
+	var entry *Entry
+	if sheetID != 0 {
+		entry, err = a.repo.GetRunningSheetEntry(sheetID)
+		if err != nil {
+			return err
 		}
+	}
+	// with single timer the running entry is unambiguous even if
+	// active sheet was changed after it was started
+	if entry == nil && sheet == "" && !a.config.TimerPerSheet {
+		entry, err = a.repo.GetRunningEntry()
+		if err != nil {
+			return err
+		}
+	}
+	if entry == nil {
+		if sheet != "" {
+			return fmt.Errorf("No running entry on sheet %s", sheet)
+		}
+		return fmt.Errorf("No running entry to stop")
+	}
+
+	endTime := a.timeParser.Current()
+	if at != "" {
+		endTime, err = a.timeParser.Parse(at)
+		if err != nil {
+			return err
+		}
+	}
+	if endTime.Before(entry.StartTime) {
+		return fmt.Errorf("End time %s is before start time %s", endTime.Format("2006-01-02 15:04:05"), entry.StartTime.Format("2006-01-02 15:04:05"))
+	}
+
+	if entry.Note == "" && note == "" {
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Print("Enter a note (press Enter to skip): ")
+		inputNote, _ := reader.ReadString('\n')
+		note = strings.TrimSpace(inputNote)
+	}
 
-		IDs = append(IDs, int64(entry.ID))
+	if err := a.repo.StopEntry(entry, endTime, note); err != nil {
+		return err
 	}
 
-	msg, err := apiClient.MarkEntriesAsImported(IDs)
+	fmt.Println("Tracking stopped!")
+	return nil
+}
 
-	return msg, nil
+// starts new entry with sheet and note of entry with id, last finished
+// entry if id is 0, or of note picked from menu of recent notes
+func (a *App) Resume(id int64, pick bool, at string) error {
+	var entry *Entry
+	var err error
+
+	switch {
+	case pick:
+		var notes []RecentNote
+		notes, err = a.repo.GetRecentNotes(recentNotesLimit)
+		if err != nil {
+			return err
+		}
+		if len(notes) == 0 {
+			return fmt.Errorf("No notes to resume")
+		}
+
+		menu := gocliselect.NewMenu("Resume")
+		for _, recent := range notes {
+			menu.AddItem(fmt.Sprintf("%s: %s", recent.Sheet, recent.Note), recent)
+		}
+		menu.EnableSkip("cancel")
+
+		selected, _ := menu.Display()
+		if selected == nil {
+			return nil
+		}
+
+		entry, err = a.repo.GetEntryByID(selected.(RecentNote).EntryID)
+	case id == 0:
+		entry, err = a.repo.GetLastFinishedEntry()
+	default:
+		entry, err = a.repo.GetEntryByID(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	sheetName, err := a.repo.GetSheetNameByID(entry.SheetID)
+	if err != nil {
+		return err
+	}
+	note := entry.Note
+
+	if err := a.checkNotArchived(sheetName); err != nil {
+		return err
+	}
+
+	// resumed entry gets all tags of original one, not only its hashtags
+	tags, err := a.repo.GetEntryTags(entry.ID)
+	if err != nil {
+		return err
+	}
+
+	startTime := a.timeParser.Current()
+	if at != "" {
+		startTime, err = a.timeParser.Parse(at)
+		if err != nil {
+			return err
+		}
+	}
+
+	sheetID, err := a.repo.GetSheetIdByName(sheetName)
+	if err != nil {
+		return err
+	}
+	running, runningSheet, err := a.runningBeforeStart(sheetID, startTime)
+	if err != nil {
+		return err
+	}
+
+	// resumed entry is tracked on its own sheet, sheet becomes active only
+	// together with the new entry
+	if err := a.repo.SwitchEntry(running, sheetName, startTime, note, tags); err != nil {
+		return err
+	}
+
+	if running != nil {
+		fmt.Printf("Stopped running entry on sheet %s.\n", runningSheet)
+	}
+	if note != "" {
+		fmt.Printf("Resumed '%s' on sheet %s at %s\n", note, sheetName, startTime.Format("15:04:05"))
+	} else {
+		fmt.Printf("Resumed sheet %s at %s\n", sheetName, startTime.Format("15:04:05"))
+	}
+	return nil
+}
+
+// number of recent notes offered by resume picker
+const recentNotesLimit = 15
+
+// prints active sheet, running entry and today's total of active sheet,
+// output can be customized with text/template format
+func (a *App) Status(format string) error {
+	now := a.timeParser.Current()
+	var info StatusInfo
+
+	sheet, err := a.repo.GetActiveSheet()
+	if err != nil {
+		return err
+	}
+
+	// prefer entry running on active sheet
+	var running *Entry
+	if sheet != nil {
+		running, err = a.repo.GetRunningSheetEntry(sheet.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if running == nil {
+		running, err = a.repo.GetRunningEntry()
+		if err != nil {
+			return err
+		}
+	}
+	if running != nil {
+		info.Running = true
+		info.Note = running.Note
+		info.StartTime = running.StartTime
+
+		elapsed := now.Sub(running.StartTime)
+		info.Elapsed = FormatDuration(elapsed)
+		info.ElapsedSeconds = int64(elapsed.Seconds())
+
+		info.RunningSheet, err = a.repo.GetSheetNameByID(running.SheetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if sheet != nil {
+		info.Sheet = sheet.Name
+
+		dayStart, err := a.config.DayStartOffset()
+		if err != nil {
+			return err
+		}
+		startTime, endTime, err := PeriodRange("day", now, 0, dayStart)
+		if err != nil {
+			return err
+		}
+
+		entries, err := a.repo.GetOverlappingEntries(sheet.ID, startTime, endTime)
+		if err != nil {
+			return err
+		}
+
+		// entries crossing day boundaries count only with their part in
+		// today, running entry counts until now
+		var today time.Duration
+		for _, entry := range entries {
+			end := entry.EndTime
+			if end.IsZero() {
+				end = now
+			}
+			today += ClipDuration(entry.StartTime, end, startTime, endTime)
+		}
+		info.Today = FormatDuration(today)
+		info.TodaySeconds = int64(today.Seconds())
+	}
+
+	if format == "" {
+		format = defaultStatusFormat
+	}
+
+	tmpl, err := template.New("status").Parse(format)
+	if err != nil {
+		return fmt.Errorf("Invalid status format: %w", err)
+	}
+
+	return tmpl.Execute(os.Stdout, info)
+}
+
+const defaultStatusFormat = `Sheet: {{if .Sheet}}{{.Sheet}}{{else}}none{{end}}
+{{if .Running}}Running: {{if .Note}}{{.Note}} {{end}}on {{.RunningSheet}} for {{.Elapsed}} (since {{.StartTime.Format "15:04"}}){{else}}Not running{{end}}
+{{if .Sheet}}Today: {{.Today}}
+{{end}}`
+
+func (a *App) Display(opts DisplayOptions) error {
+	sheets, err := a.displaySheets(opts)
+	if err != nil {
+		return err
+	}
+
+	dayStart, err := a.config.DayStartOffset()
+	if err != nil {
+		return err
+	}
+
+	formatter, err := NewFormatter(opts.Format, dayStart)
+	if err != nil {
+		return err
+	}
+
+	return formatter.Format(os.Stdout, sheets)
+}
+
+// writes entries in same range as display to output file (stdout if empty)
+func (a *App) Export(opts ExportOptions) error {
+	sheets, err := a.displaySheets(opts.DisplayOptions)
+	if err != nil {
+		return err
+	}
+
+	dayStart, err := a.config.DayStartOffset()
+	if err != nil {
+		return err
+	}
+
+	formatter, err := NewExportFormatter(opts, dayStart)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output == "" {
+		return formatter.Format(os.Stdout, sheets)
+	}
+
+	file, err := os.Create(opts.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	if err := formatter.Format(file, sheets); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported entries to %s\n", opts.Output)
+	return file.Close()
+}
+
+// returns sheets with finished entries in displayed range, filtered by tags
+func (a *App) displaySheets(opts DisplayOptions) ([]Sheet, error) {
+	startTime, endTime, err := a.displayRange(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := NormalizeTags(opts.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets, err := a.repo.GetSheetsWithEntries(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterSheetsByTags(sheets, tags), nil
+}
+
+// resolves time range displayed by explicit start/end or by named period
+// containing reference date, shifted by offset periods
+func (a *App) displayRange(opts DisplayOptions) (time.Time, time.Time, error) {
+	var startTime, endTime time.Time
+	var err error
+
+	if opts.Start != "" {
+		startTime, err = a.timeParser.Parse(opts.Start)
+		if err != nil {
+			return startTime, endTime, err
+		}
+
+		endTime = a.timeParser.Current()
+		if opts.End != "" {
+			endTime, err = a.timeParser.Parse(opts.End)
+			if err != nil {
+				return startTime, endTime, err
+			}
+		}
+
+		if !endTime.After(startTime) {
+			return startTime, endTime, fmt.Errorf("End of range must be after start")
+		}
+		return startTime, endTime, nil
+	}
+	if opts.End != "" {
+		return startTime, endTime, fmt.Errorf("--end requires --start")
+	}
+
+	now := a.timeParser.Current()
+	if opts.Date != "" {
+		now, err = a.timeParser.Parse(opts.Date)
+		if err != nil {
+			return startTime, endTime, err
+		}
+	}
+
+	dayStart, err := a.config.DayStartOffset()
+	if err != nil {
+		return startTime, endTime, err
+	}
+
+	return PeriodRange(opts.Type, now, opts.Offset, dayStart)
+}
+
+func (a *App) EditEntry(id int64, opts EditOptions) error {
+	var entry *Entry
+	var err error
+
+	// default to last entry when id is not provided
+	if id == 0 {
+		entry, err = a.repo.GetLastEntry()
+	} else {
+		entry, err = a.repo.GetEntryByID(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Start != nil {
+		entry.StartTime, err = a.timeParser.Parse(*opts.Start)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.End != nil {
+		entry.EndTime, err = a.timeParser.Parse(*opts.End)
+		if err != nil {
+			return err
+		}
+	}
+	entry.Tags, err = a.repo.GetEntryTags(entry.ID)
+	if err != nil {
+		return err
+	}
+	if opts.Note != nil {
+		entry.Tags, err = RetagNote(entry.Tags, entry.Note, *opts.Note)
+		if err != nil {
+			return err
+		}
+		entry.Note = *opts.Note
+	}
+	if opts.Sheet != nil {
+		entry.SheetID, err = a.repo.GetSheetIdByName(*opts.Sheet)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !entry.EndTime.IsZero() && entry.EndTime.Before(entry.StartTime) {
+		return fmt.Errorf("End time must be after start time")
+	}
+
+	if err := a.repo.EditEntry(entry); err != nil {
+		return err
+	}
+
+	fmt.Printf("Entry %d updated!\n", entry.ID)
+	return nil
+}
+
+// source of entries imported from telegram bot
+const telegramSource = "telegram"
+
+func (a *App) Import(ctx context.Context, sourceName string, opts TelegramImportOptions) (string, error) {
+	source, err := a.config.ResolveImportSource(sourceName)
+	if err != nil {
+		return "", err
+	}
+	token, err := source.ResolveToken()
+	if err != nil {
+		return "", err
+	}
+
+	apiClient := NewAPIClient(source.URL, token)
+	apiClient.MaxRetries = a.config.ImportRetries
+
+	var sinceTime time.Time
+	if opts.Since != "" {
+		sinceTime, err = a.timeParser.Parse(opts.Since)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	rulesPath := opts.RulesPath
+	if rulesPath == "" {
+		rulesPath = a.config.ImportRules
+	}
+	rules, err := LoadImportRules(rulesPath)
+	if err != nil {
+		return "", err
+	}
+
+	unimportedEntries, err := apiClient.GetUnimportedEntries(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// ids to mark as imported on remote
+	var IDs []int64
+	// entries stored locally by previous run which failed to mark them
+	var reconciledCount int
+
+	var rows []*importRow
+	for _, entry := range unimportedEntries {
+		// leave older entries unimported on remote
+		if entry.StartTime.Before(sinceTime) {
+			continue
+		}
+
+		exists, err := a.repo.CheckSourceEntryExists(telegramSource, int64(entry.ID))
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			reconciledCount++
+			IDs = append(IDs, int64(entry.ID))
+			continue
+		}
+
+		// bot returns UTC, preview shows times in same zone as parsed end times
+		entry.StartTime = entry.StartTime.In(a.timeParser.Location)
+
+		row := &importRow{entry: entry, note: entry.Note, rule: -1}
+		if entry.EndTime.Valid {
+			row.endTime = entry.EndTime.Time.In(a.timeParser.Location)
+		}
+
+		if j := MatchImportRule(rules, entry.Note); j >= 0 {
+			row.sheet = rules[j].Sheet
+			row.assigned = assignedRule
+			row.rule = j
+		} else if opts.DefaultSheet != "" {
+			row.sheet = opts.DefaultSheet
+			row.assigned = assignedDefault
+		}
+
+		rows = append(rows, row)
+	}
+
+	if !opts.NonInteractive && len(rows) > 0 {
+		confirmed, err := a.runImportPreview(rows, os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		if !confirmed {
+			return "Import cancelled.", nil
+		}
+	}
+
+	// number of entries imported by each rule, default sheet and manually
+	ruleCounts := make([]int, len(rules))
+	var defaultCount, manualCount, skippedCount, runningCount, droppedCount int
+
+	var entries []ImportedEntry
+	for _, row := range rows {
+		switch {
+		case row.dropped:
+			// dropped entries are only marked, so they leave the queue
+			droppedCount++
+			IDs = append(IDs, int64(row.entry.ID))
+			continue
+		case row.sheet == "":
+			skippedCount++
+			continue
+		case row.running():
+			// never import running entry open-ended, it stays on remote
+			runningCount++
+			continue
+		}
+
+		switch row.assigned {
+		case assignedRule:
+			ruleCounts[row.rule]++
+		case assignedDefault:
+			defaultCount++
+		default:
+			manualCount++
+		}
+
+		entries = append(entries, ImportedEntry{
+			Sheet:     row.sheet,
+			StartTime: row.entry.StartTime,
+			EndTime:   sql.NullTime{Time: row.endTime, Valid: true},
+			Note:      row.note,
+			Source:    telegramSource,
+			SourceID:  int64(row.entry.ID),
+		})
+		IDs = append(IDs, int64(row.entry.ID))
+	}
+
+	// all or nothing, sheets from rules and default sheet are created on first use
+	if _, err := a.repo.CreateImportedEntries(entries); err != nil {
+		return "", err
+	}
+
+	// summary of where entries went
+	for i := range rules {
+		if ruleCounts[i] > 0 {
+			fmt.Printf("Rule %s: %d entries\n", rules[i].String(), ruleCounts[i])
+		}
+	}
+	if defaultCount > 0 {
+		fmt.Printf("Default sheet %s: %d entries\n", opts.DefaultSheet, defaultCount)
+	}
+	if manualCount > 0 {
+		fmt.Printf("Assigned manually: %d entries\n", manualCount)
+	}
+	if skippedCount > 0 {
+		fmt.Printf("Skipped (no sheet): %d entries\n", skippedCount)
+	}
+	if runningCount > 0 {
+		fmt.Printf("Skipped (still running): %d entries\n", runningCount)
+	}
+	if droppedCount > 0 {
+		fmt.Printf("Dropped: %d entries\n", droppedCount)
+	}
+	if reconciledCount > 0 {
+		fmt.Printf("Already imported, marking again: %d entries\n", reconciledCount)
+	}
+
+	if len(IDs) == 0 {
+		return "No entries imported.", nil
+	}
+
+	// entries are stored locally at this point, if marking fails (after client
+	// retries) they are reconciled on next run instead of being imported twice
+	msg, err := apiClient.MarkEntriesAsImported(ctx, IDs)
+	if err != nil {
+		return "", fmt.Errorf("entries were imported but marking them on remote failed (will retry on next import): %w", err)
+	}
+
+	return msg, nil
+}
+
+// imports entries from timetrap database, entries which already exist
+// are skipped so import can be repeated safely
+func (a *App) ImportTimetrap(path string, since string) (string, error) {
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		sinceTime, err = a.timeParser.Parse(since)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	entries, currentSheet, err := ReadTimetrapDatabase(path)
+	if err != nil {
+		return "", err
+	}
+
+	var imported, duplicates, running int
+	for _, entry := range entries {
+		if entry.StartTime.Before(sinceTime) {
+			continue
+		}
+		// running timetrap entry would conflict with timetick tracking
+		if !entry.EndTime.Valid {
+			running++
+			continue
+		}
+
+		if !a.repo.CheckSheetExists(entry.Sheet) {
+			if err := a.repo.CreateSheet(entry.Sheet); err != nil {
+				return "", err
+			}
+		}
+
+		sheetID, err := a.repo.GetSheetIdByName(entry.Sheet)
+		if err != nil {
+			return "", err
+		}
+
+		exists, err := a.repo.CheckEntryExists(sheetID, entry.StartTime)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			duplicates++
+			continue
+		}
+
+		if err := a.repo.CreateFullEntry(entry.Sheet, entry.StartTime, entry.EndTime, entry.Note, nil); err != nil {
+			return "", err
+		}
+		imported++
+	}
+
+	// restore timetrap current sheet
+	if currentSheet != "" {
+		if !a.repo.CheckSheetExists(currentSheet) {
+			if err := a.repo.CreateSheet(currentSheet); err != nil {
+				return "", err
+			}
+		}
+		if err := a.repo.SetActiveSheet(currentSheet); err != nil {
+			return "", err
+		}
+	}
+
+	msg := fmt.Sprintf("Imported %d entries from timetrap, skipped %d already imported and %d running.", imported, duplicates, running)
+	if currentSheet != "" {
+		msg += fmt.Sprintf("\nActive sheet: %s", currentSheet)
+	}
+
+	return msg, nil
+}
+
+// imports entries from csv file, with dry run entries are only printed
+func (a *App) ImportCSV(path string, opts CSVImportOptions) (string, error) {
+	sheets, err := ReadCSVEntries(path, opts, a.timeParser)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun {
+		dayStart, err := a.config.DayStartOffset()
+		if err != nil {
+			return "", err
+		}
+
+		formatter := &TableFormatter{DayStart: dayStart}
+		if err := formatter.Format(os.Stdout, sheets); err != nil {
+			return "", err
+		}
+
+		var total int
+		for _, sheet := range sheets {
+			total += len(sheet.Entries)
+		}
+		return fmt.Sprintf("Dry run: %d entries would be imported.", total), nil
+	}
+
+	var imported, duplicates int
+	for _, sheet := range sheets {
+		if !a.repo.CheckSheetExists(sheet.Name) {
+			if err := a.repo.CreateSheet(sheet.Name); err != nil {
+				return "", err
+			}
+		}
+
+		sheetID, err := a.repo.GetSheetIdByName(sheet.Name)
+		if err != nil {
+			return "", err
+		}
+
+		for _, entry := range sheet.Entries {
+			exists, err := a.repo.CheckEntryExists(sheetID, entry.StartTime)
+			if err != nil {
+				return "", err
+			}
+			if exists {
+				duplicates++
+				continue
+			}
+
+			endTime := sql.NullTime{Time: entry.EndTime, Valid: true}
+			if err := a.repo.CreateFullEntry(sheet.Name, entry.StartTime, endTime, entry.Note, nil); err != nil {
+				return "", err
+			}
+			imported++
+		}
+	}
+
+	return fmt.Sprintf("Imported %d entries from csv, skipped %d already imported.", imported, duplicates), nil
+}
+
+// pending migrations are applied when database is opened, migrate reports
+// schema version or lists all migrations with their state
+func (a *App) Migrate(status bool) error {
+	if !status {
+		version, err := a.repo.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Database is up to date (schema version %d)\n", version)
+		return nil
+	}
+
+	statuses, err := a.repo.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"Version", "Name", "Applied at"}
+
+	var rows [][]string
+	for _, migration := range statuses {
+		appliedAt := "pending"
+		if !migration.AppliedAt.IsZero() {
+			appliedAt = migration.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(migration.Version),
+			migration.Name,
+			appliedAt,
+		})
+	}
+
+	PrintTable(os.Stdout, headers, rows, nil)
+	return nil
+}
+
+// copies database to path, default path is in backup directory
+func (a *App) Backup(ctx context.Context, path string) error {
+	if path == "" {
+		path = a.repo.DefaultBackupPath()
+	} else if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("File %s already exists", path)
+	}
+
+	if err := a.repo.Backup(ctx, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up database to %s\n", path)
+	return nil
+}
+
+// replaces database with backup, without path backup is picked from backup
+// directory, current database is backed up by repo first
+func (a *App) Restore(ctx context.Context, path string, yes bool) error {
+	if path == "" {
+		backups, err := a.repo.ListBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			return fmt.Errorf("No backups found, pass path of backup to restore")
+		}
+
+		menu := gocliselect.NewMenu("Restore backup")
+		for _, backup := range backups {
+			menu.AddItem(filepath.Base(backup), backup)
+		}
+		menu.EnableSkip("cancel")
+
+		selected, _ := menu.Display()
+		if selected == nil {
+			return nil
+		}
+		path = selected.(string)
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Replace all sheets and entries with backup %s?", path)) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	previous, err := a.repo.Restore(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored database from %s, previous database saved to %s\n", path, previous)
+	return nil
 }
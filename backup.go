@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	integrityCheckSQL = `PRAGMA integrity_check`
+
+	// number of automatic backups kept, older ones are removed
+	autoBackupKeep = 10
+	// layout of time in backup file names, sorts chronologically
+	backupTimeLayout = "20060102-150405.000"
+)
+
+// columns of schema before versioned migrations, migrations and queries
+// rely on them so backup without them is not restored
+var requiredBackupColumns = map[string][]string{
+	"sheets":  {"id", "name", "active"},
+	"entries": {"id", "sheet_id", "start_time", "end_time", "note", "created_at"},
+}
+
+// copies live database to path using SQLite online backup API, so it is
+// consistent even while database is in use, existing file is replaced
+func (r *Repo) Backup(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// backup is written next to target and renamed when complete
+	tmpPath := path + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	destURI, err := fileURI(tmpPath, "")
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	dest, err := sql.Open("sqlite3", destURI)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	if err := copyDatabase(ctx, dest, r.db); err != nil {
+		dest.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	if err := dest.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// returns path of manual backup created without explicit path
+func (r *Repo) DefaultBackupPath() string {
+	name := fmt.Sprintf("database-%s.db", time.Now().Format(backupTimeLayout))
+	return filepath.Join(r.backupDir, name)
+}
+
+// creates backup before risky operation (e.g. "migrate", "sheet-delete"),
+// only newest autoBackupKeep automatic backups are kept
+func (r *Repo) AutoBackup(reason string) (string, error) {
+	name := fmt.Sprintf("auto-%s-%s.db", time.Now().Format(backupTimeLayout), reason)
+	path := filepath.Join(r.backupDir, name)
+
+	if err := r.Backup(context.Background(), path); err != nil {
+		return "", err
+	}
+
+	return path, r.rotateAutoBackups()
+}
+
+// removes oldest automatic backups above limit
+func (r *Repo) rotateAutoBackups() error {
+	paths, err := filepath.Glob(filepath.Join(r.backupDir, "auto-*.db"))
+	if err != nil {
+		return err
+	}
+	if len(paths) <= autoBackupKeep {
+		return nil
+	}
+
+	// names start with time, so sorting by name sorts by age
+	sort.Strings(paths)
+	for _, path := range paths[:len(paths)-autoBackupKeep] {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// replaces content of live database with backup at path, backup is
+// validated first and migrated to current schema afterwards, returns path
+// of automatic backup of replaced database. if backup can't be copied or
+// migrated, replaced database is put back
+func (r *Repo) Restore(ctx context.Context, path string) (string, error) {
+	src, err := openBackup(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if err := validateBackup(src); err != nil {
+		return "", fmt.Errorf("invalid backup %s: %w", path, err)
+	}
+
+	previous, err := r.AutoBackup("restore")
+	if err != nil {
+		return "", fmt.Errorf("failed to back up database before restore: %w", err)
+	}
+
+	// backup made by older version gets missing migrations
+	err = copyDatabase(ctx, r.db, src)
+	if err == nil {
+		err = r.runMigrations()
+	}
+	if err != nil {
+		if rollbackErr := r.rollbackRestore(ctx, previous); rollbackErr != nil {
+			return previous, fmt.Errorf("failed to restore backup: %w, putting back previous database failed too: %v, it is saved in %s", err, rollbackErr, previous)
+		}
+		return previous, fmt.Errorf("failed to restore backup, previous database was put back: %w", err)
+	}
+
+	return previous, nil
+}
+
+// copies database saved before restore back into live database
+func (r *Repo) rollbackRestore(ctx context.Context, previous string) error {
+	src, err := openBackup(previous)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return copyDatabase(ctx, r.db, src)
+}
+
+// opens backup read only
+func openBackup(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	uri, err := fileURI(path, "mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	return db, nil
+}
+
+// returns SQLite URI of file with query, path is escaped so names with
+// '?', '#' or '%' are not taken as URI parameters
+func fileURI(path string, query string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(absPath), RawQuery: query}
+	return uri.String(), nil
+}
+
+// checks that database is intact and has schema this version can use
+func validateBackup(db *sql.DB) error {
+	var result string
+	if err := db.QueryRow(integrityCheckSQL).Scan(&result); err != nil {
+		return fmt.Errorf("not a timetick database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	for _, table := range []string{"sheets", "entries"} {
+		exists, err := tableExists(db, table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("not a timetick database: missing table %s", table)
+		}
+
+		columns, err := tableColumns(db, table)
+		if err != nil {
+			return err
+		}
+		for _, column := range requiredBackupColumns[table] {
+			if !columns[column] {
+				return fmt.Errorf("not a timetick database: missing column %s.%s", table, column)
+			}
+		}
+	}
+
+	// databases from before versioned migrations have no schema_migrations
+	exists, err := tableExists(db, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	if exists {
+		var version int
+		if err := db.QueryRow(getSchemaVersionSQL).Scan(&version); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		if version > latestSchemaVersion() {
+			return fmt.Errorf("schema version %d is newer than version %d supported by this timetick", version, latestSchemaVersion())
+		}
+	}
+
+	return nil
+}
+
+// checks if table exists in database
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var exists bool
+	if err := db.QueryRow(checkTableExistsSQL, table).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return exists, nil
+}
+
+// returns set of column names of table
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table info: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// copies main database of src into dest using SQLite online backup API
+func copyDatabase(ctx context.Context, dest *sql.DB, src *sql.DB) error {
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriverConn any) error {
+		return srcConn.Raw(func(srcDriverConn any) error {
+			destSQLite, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver")
+			}
+			srcSQLite, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected database driver")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+
+			// -1 copies all pages in single step
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// lists backups in backup directory, newest first
+func (r *Repo) ListBackups() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(r.backupDir, "*.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return backupTime(paths[i]) > backupTime(paths[j])
+	})
+	return paths, nil
+}
+
+// returns time part of backup file name
+func backupTime(path string) string {
+	name := filepath.Base(path)
+	name = strings.TrimPrefix(name, "auto-")
+	name = strings.TrimPrefix(name, "database-")
+	return name
+}
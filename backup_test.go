@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// repo on fresh database in temporary directory
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+
+	repo, err := NewRepo(filepath.Join(t.TempDir(), "timetick.db"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// adds finished entry to sheet, sheet is created if missing
+func seedEntry(t *testing.T, repo *Repo, sheet string, note string) {
+	t.Helper()
+
+	if !repo.CheckSheetExists(sheet) {
+		if err := repo.CreateSheet(sheet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	end := sql.NullTime{Time: start.Add(time.Hour), Valid: true}
+	if err := repo.CreateFullEntry(sheet, start, end, note, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// returns notes of all entries in database
+func entryNotes(t *testing.T, repo *Repo) []string {
+	t.Helper()
+
+	rows, err := repo.db.Query(`SELECT note FROM entries ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var notes []string
+	for rows.Next() {
+		var note string
+		if err := rows.Scan(&note); err != nil {
+			t.Fatal(err)
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+// creates database file at path with given statements
+func createDatabase(t *testing.T, path string, statements ...string) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	repo := newTestRepo(t)
+	seedEntry(t, repo, "work", "before backup")
+
+	// name with characters which have meaning in URI
+	path := filepath.Join(t.TempDir(), "odd?name#1%20.db")
+	if err := repo.Backup(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	seedEntry(t, repo, "work", "after backup")
+
+	previous, err := repo.Restore(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := entryNotes(t, repo); len(got) != 1 || got[0] != "before backup" {
+		t.Errorf("restored entries %v, want [before backup]", got)
+	}
+	if _, err := os.Stat(previous); err != nil {
+		t.Errorf("backup of replaced database is missing: %v", err)
+	}
+
+	// replaced database can be restored back
+	if _, err := repo.Restore(context.Background(), previous); err != nil {
+		t.Fatal(err)
+	}
+	if got := entryNotes(t, repo); len(got) != 2 {
+		t.Errorf("got %d entries after restoring previous database, want 2", len(got))
+	}
+}
+
+func TestRotateAutoBackups(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := os.MkdirAll(repo.backupDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for i := 0; i < autoBackupKeep+3; i++ {
+		name := fmt.Sprintf("auto-20261015-0900%02d.000-migrate.db", i)
+		if err := os.WriteFile(filepath.Join(repo.backupDir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	// manual backups are never rotated
+	manual := filepath.Join(repo.backupDir, "database-20261001-090000.000.db")
+	if err := os.WriteFile(manual, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.rotateAutoBackups(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(repo.backupDir, name))
+		if kept := err == nil; kept != (i >= 3) {
+			t.Errorf("%s kept: %v, want %v", name, kept, i >= 3)
+		}
+	}
+	if _, err := os.Stat(manual); err != nil {
+		t.Errorf("manual backup was removed: %v", err)
+	}
+}
+
+func TestRestoreRefusesNewerSchema(t *testing.T) {
+	repo := newTestRepo(t)
+	seedEntry(t, repo, "work", "live")
+
+	path := filepath.Join(t.TempDir(), "newer.db")
+	if err := repo.Backup(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	createDatabase(t, path, fmt.Sprintf(`INSERT INTO schema_migrations (version, name) VALUES (%d, 'from future')`, latestSchemaVersion()+1))
+
+	_, err := repo.Restore(context.Background(), path)
+	if err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("got error %v, want newer schema refusal", err)
+	}
+	if got := entryNotes(t, repo); len(got) != 1 || got[0] != "live" {
+		t.Errorf("live database changed to %v", got)
+	}
+}
+
+func TestRestoreRefusesForeignDatabase(t *testing.T) {
+	repo := newTestRepo(t)
+	seedEntry(t, repo, "work", "live")
+
+	path := filepath.Join(t.TempDir(), "foreign.db")
+	createDatabase(t, path,
+		`CREATE TABLE sheets (id INTEGER PRIMARY KEY, title TEXT)`,
+		`CREATE TABLE entries (id INTEGER PRIMARY KEY, body TEXT)`,
+	)
+
+	_, err := repo.Restore(context.Background(), path)
+	if err == nil || !strings.Contains(err.Error(), "missing column") {
+		t.Fatalf("got error %v, want missing column", err)
+	}
+	if got := entryNotes(t, repo); len(got) != 1 || got[0] != "live" {
+		t.Errorf("live database changed to %v", got)
+	}
+}
+
+func TestRestoreRollsBackFailedMigration(t *testing.T) {
+	repo := newTestRepo(t)
+	seedEntry(t, repo, "work", "live")
+
+	// valid pre-migration schema, but duplicate sources make unique index
+	// of migration 2 fail
+	path := filepath.Join(t.TempDir(), "broken.db")
+	createDatabase(t, path,
+		createSheetsTableSQL,
+		createEntriesTableSQL,
+		addEntriesSourceColumnSQL,
+		addEntriesSourceIDColumnSQL,
+		createSchemaMigrationsTableSQL,
+		`INSERT INTO schema_migrations (version, name) VALUES (1, 'create sheets and entries')`,
+		`INSERT INTO sheets (name) VALUES ('old')`,
+		`INSERT INTO entries (sheet_id, start_time, note, source, source_id) VALUES (1, '2026-10-01 09:00:00+00:00', 'a', 'telegram', 1)`,
+		`INSERT INTO entries (sheet_id, start_time, note, source, source_id) VALUES (1, '2026-10-01 10:00:00+00:00', 'b', 'telegram', 1)`,
+	)
+
+	_, err := repo.Restore(context.Background(), path)
+	if err == nil || !strings.Contains(err.Error(), "put back") {
+		t.Fatalf("got error %v, want failed migration", err)
+	}
+
+	if got := entryNotes(t, repo); len(got) != 1 || got[0] != "live" {
+		t.Errorf("live database changed to %v", got)
+	}
+	version, err := repo.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("schema version %d after rollback, want %d", version, latestSchemaVersion())
+	}
+}
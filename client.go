@@ -2,11 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"math/rand"
 	"net/http"
-	"os"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -43,127 +47,195 @@ type (
 
 type APIClient struct {
 	baseURL    string
+	token      string
 	httpClient *http.Client
+
+	// number of retries of failed request (network error, 429 or 5xx)
+	MaxRetries int
+	// delay before first retry, doubled on every next retry
+	BaseDelay time.Duration
+	// upper limit of delay between retries
+	MaxDelay time.Duration
+	// number of entries requested per page
+	PageSize int
 }
 
-func NewAPIClient(baseURL string) *APIClient {
+func NewAPIClient(baseURL string, token string) *APIClient {
 	return &APIClient{
-		baseURL: baseURL,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+		PageSize:   100,
 	}
 }
 
-// fetches all unimported entries from the API
-func (c *APIClient) GetUnimportedEntries() ([]APIEntry, error) {
-	url := fmt.Sprintf("%s/api/entries", c.baseURL)
+// fetches all unimported entries from the API, following pages until
+// total reported by API is reached
+func (c *APIClient) GetUnimportedEntries(ctx context.Context) ([]APIEntry, error) {
+	var entries []APIEntry
+	seen := make(map[int]bool)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
+	for {
+		query := url.Values{}
+		query.Set("offset", strconv.Itoa(len(entries)))
+		query.Set("limit", strconv.Itoa(c.PageSize))
+
+		var page EntriesResponse
+		if err := c.doJSON(ctx, http.MethodGet, "/api/entries?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, entry := range page.Entries {
+			if !seen[entry.ID] {
+				seen[entry.ID] = true
+				entries = append(entries, entry)
+				added++
+			}
+		}
 
-	apiToken := os.Getenv("API_TOKEN")
-	if apiToken == "" {
-		log.Fatal("API_TOKEN environment variable is required")
+		// stop when everything is fetched or API does not support pagination
+		if len(entries) >= page.Total || added == 0 {
+			break
+		}
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
 
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
+	return entries, nil
+}
+
+// send requests to mark specified entries as imported
+func (c *APIClient) MarkEntriesAsImported(ctx context.Context, entryIDs []int64) (string, error) {
+	reqData := struct {
+		EntryIDs []int64 `json:"entry_ids"`
+	}{EntryIDs: entryIDs}
+
+	var res MarkImportedResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/entries/mark", reqData, &res); err != nil {
+		return "", err
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != http.StatusOK {
-		var errRes Response
-		if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil {
-			return nil, fmt.Errorf("error decoding error response: %w", err)
+	return fmt.Sprintf("Successfully imported %d entries, %d remaining on remote.", res.ImportedCount, res.RemainingCount), nil
+}
+
+// sends request with json body (if not nil) and decodes data of successful
+// response into out, failed requests are retried with exponential backoff
+func (c *APIClient) doJSON(ctx context.Context, method string, path string, body any, out any) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
 		}
-		return nil, fmt.Errorf("%s", errRes.Message)
+	}
+
+	if c.token == "" {
+		return fmt.Errorf("missing API token for %s", c.baseURL)
 	}
 
 	var apiRes Response
-	if err := json.NewDecoder(res.Body).Decode(&apiRes); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	for attempt := 0; ; attempt++ {
+		retry, err := c.send(ctx, method, c.baseURL+path, reqBody, &apiRes)
+		if err == nil {
+			break
+		}
+		if !retry || attempt >= c.MaxRetries {
+			return err
+		}
+
+		if err := sleepContext(ctx, c.backoff(attempt)); err != nil {
+			return err
+		}
 	}
 
 	if !apiRes.Success {
-		return nil, fmt.Errorf("%s", apiRes.Message)
+		return fmt.Errorf("%s", apiRes.Message)
 	}
 
-	// Convert the Data field to EntriesResponse
+	// convert the Data field to expected response
 	dataJSON, err := json.Marshal(apiRes.Data)
 	if err != nil {
-		return nil, fmt.Errorf("error re-encoding data: %w", err)
+		return fmt.Errorf("error re-encoding data: %w", err)
 	}
-
-	var entriesResp EntriesResponse
-	// here breaks
-	if err := json.Unmarshal(dataJSON, &entriesResp); err != nil {
-		fmt.Println(err)
-		return nil, fmt.Errorf("error decoding entries data: %w", err)
+	if err := json.Unmarshal(dataJSON, out); err != nil {
+		return fmt.Errorf("error decoding response data: %w", err)
 	}
 
-	return entriesResp.Entries, nil
+	return nil
 }
 
-// send requests to mark specified entries as imported
-func (c *APIClient) MarkEntriesAsImported(entryIDs []int64) (string, error) {
-	url := fmt.Sprintf("%s/api/entries/mark", c.baseURL)
-	reqData := struct {
-		EntryIDs []int64 `json:"entry_ids"`
-	}{EntryIDs: entryIDs}
-
-	reqBody, err := json.Marshal(reqData)
-	if err != nil {
-		return "", fmt.Errorf("error encoding request: %w", err)
+// sends single request and decodes response envelope, reports whether
+// failed request is worth retrying
+func (c *APIClient) send(ctx context.Context, method string, url string, reqBody []byte, apiRes *Response) (bool, error) {
+	var bodyReader io.Reader
+	if reqBody != nil {
+		bodyReader = bytes.NewReader(reqBody)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(reqBody))
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+		return false, fmt.Errorf("error creating request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	apiToken := os.Getenv("API_TOKEN")
-	if apiToken == "" {
-		log.Fatal("API_TOKEN environment variable is required")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.token))
 
-	resp, err := c.httpClient.Do(req)
+	res, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %w", err)
+		// cancelled by user, nothing to retry
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, fmt.Errorf("error making request: %w", err)
 	}
-	defer resp.Body.Close()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
 
-	if resp.StatusCode != http.StatusOK {
 		var errRes Response
-		if err := json.NewDecoder(resp.Body).Decode(&errRes); err != nil {
-			return "", fmt.Errorf("error decoding error response: %w", err)
+		if err := json.NewDecoder(res.Body).Decode(&errRes); err != nil || errRes.Message == "" {
+			return retry, fmt.Errorf("request failed with status %s", res.Status)
 		}
-		return "", fmt.Errorf("%s", errRes.Message)
+		return retry, fmt.Errorf("%s", errRes.Message)
 	}
 
-	var apiRes Response
-	if err := json.NewDecoder(resp.Body).Decode(&apiRes); err != nil {
-		return "", fmt.Errorf("error decoding response: %w", err)
+	if err := json.NewDecoder(res.Body).Decode(apiRes); err != nil {
+		return false, fmt.Errorf("error decoding response: %w", err)
 	}
 
-	if !apiRes.Success {
-		return "", fmt.Errorf(apiRes.Message)
+	return false, nil
+}
+
+// returns delay before retry, exponential with random jitter in [delay/2, delay]
+func (c *APIClient) backoff(attempt int) time.Duration {
+	delay := c.BaseDelay << attempt
+	if delay > c.MaxDelay || delay <= 0 {
+		delay = c.MaxDelay
 	}
 
-	dataJSON, err := json.Marshal(apiRes.Data)
-	if err != nil {
-		return "", fmt.Errorf("error re-encoding data: %w", err)
+	half := delay / 2
+	if half <= 0 {
+		return delay
 	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
 
-	var res MarkImportedResponse
-	if err := json.Unmarshal(dataJSON, &res); err != nil {
-		return "", fmt.Errorf("error decoding mark response data: %w", err)
+// sleeps for duration unless context is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return fmt.Sprintf("Successfully imported %d of %d entries.", res.ImportedCount, res.RemainingCount), nil
 }
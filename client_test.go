@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writes response envelope used by telegram bot
+func writeAPIResponse(w http.ResponseWriter, status int, res Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+// client with tiny backoff so retries do not slow tests down
+func newTestAPIClient(url string) *APIClient {
+	client := NewAPIClient(url, "secret")
+	client.BaseDelay = time.Millisecond
+	client.MaxDelay = 5 * time.Millisecond
+	return client
+}
+
+// stand-in for bot serving entries with given ids, pages are served by
+// offset and limit unless ignoreOffset is set
+func newEntriesServer(t *testing.T, ids []int, ignoreOffset bool, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			writeAPIResponse(w, http.StatusUnauthorized, Response{Message: "unauthorized"})
+			return
+		}
+		if r.URL.Path != "/api/entries" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if ignoreOffset {
+			offset = 0
+		}
+
+		page := EntriesResponse{Total: len(ids), Entries: []APIEntry{}}
+		for i := offset; i < len(ids) && i < offset+limit; i++ {
+			page.Entries = append(page.Entries, APIEntry{
+				ID:        ids[i],
+				StartTime: time.Date(2026, 10, 15, 9, i, 0, 0, time.UTC),
+				Note:      "entry " + strconv.Itoa(ids[i]),
+			})
+		}
+
+		writeAPIResponse(w, http.StatusOK, Response{Success: true, Data: page})
+	}))
+}
+
+func TestGetUnimportedEntriesPagination(t *testing.T) {
+	var requests int32
+	server := newEntriesServer(t, []int{1, 2, 3, 4, 5}, false, &requests)
+	defer server.Close()
+
+	client := newTestAPIClient(server.URL)
+	client.PageSize = 2
+
+	entries, err := client.GetUnimportedEntries(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.ID != i+1 {
+			t.Errorf("entry %d has id %d, want %d", i, entry.ID, i+1)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("made %d requests, want 3", n)
+	}
+}
+
+func TestGetUnimportedEntriesServerIgnoresOffset(t *testing.T) {
+	var requests int32
+	server := newEntriesServer(t, []int{1, 2, 3, 4, 5}, true, &requests)
+	defer server.Close()
+
+	client := newTestAPIClient(server.URL)
+	client.PageSize = 2
+
+	entries, err := client.GetUnimportedEntries(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// second page repeats first one, so fetching stops instead of looping
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(entries))
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestGetUnimportedEntriesRetries(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusTooManyRequests} {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// fail twice, then succeed
+			if atomic.AddInt32(&requests, 1) <= 2 {
+				writeAPIResponse(w, status, Response{Message: "try again"})
+				return
+			}
+			page := EntriesResponse{Total: 1, Entries: []APIEntry{{ID: 7}}}
+			writeAPIResponse(w, http.StatusOK, Response{Success: true, Data: page})
+		}))
+
+		entries, err := newTestAPIClient(server.URL).GetUnimportedEntries(context.Background())
+		server.Close()
+
+		if err != nil {
+			t.Errorf("status %d: unexpected error: %v", status, err)
+			continue
+		}
+		if len(entries) != 1 || entries[0].ID != 7 {
+			t.Errorf("status %d: got %+v, want entry 7", status, entries)
+		}
+		if n := atomic.LoadInt32(&requests); n != 3 {
+			t.Errorf("status %d: made %d requests, want 3", status, n)
+		}
+	}
+}
+
+func TestGetUnimportedEntriesGivesUp(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		writeAPIResponse(w, http.StatusServiceUnavailable, Response{Message: "down for maintenance"})
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server.URL)
+	client.MaxRetries = 2
+
+	_, err := client.GetUnimportedEntries(context.Background())
+	if err == nil || err.Error() != "down for maintenance" {
+		t.Errorf("got error %v, want message from server", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("made %d requests, want 3", n)
+	}
+}
+
+func TestGetUnimportedEntriesNoRetryOnClientError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			writeAPIResponse(w, status, Response{Message: "bad request"})
+		}))
+
+		_, err := newTestAPIClient(server.URL).GetUnimportedEntries(context.Background())
+		server.Close()
+
+		if err == nil || err.Error() != "bad request" {
+			t.Errorf("status %d: got error %v, want message from server", status, err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("status %d: made %d requests, want 1", status, n)
+		}
+	}
+}
+
+func TestGetUnimportedEntriesCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		writeAPIResponse(w, http.StatusInternalServerError, Response{Message: "fail"})
+		// user interrupts while client waits before retry
+		cancel()
+	}))
+	defer server.Close()
+
+	client := newTestAPIClient(server.URL)
+	client.BaseDelay = time.Hour
+	client.MaxDelay = time.Hour
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetUnimportedEntries(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got error %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client kept waiting after context was cancelled")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("made %d requests, want 1", n)
+	}
+}
+
+func TestMarkEntriesAsImported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/entries/mark" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+
+		var body struct {
+			EntryIDs []int64 `json:"entry_ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+
+		res := MarkImportedResponse{ImportedCount: len(body.EntryIDs), RemainingCount: 1}
+		writeAPIResponse(w, http.StatusOK, Response{Success: true, Data: res})
+	}))
+	defer server.Close()
+
+	message, err := newTestAPIClient(server.URL).MarkEntriesAsImported(context.Background(), []int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Successfully imported 2 entries, 1 remaining on remote."; message != want {
+		t.Errorf("got message %q, want %q", message, want)
+	}
+}
+
+func TestMissingToken(t *testing.T) {
+	client := NewAPIClient("http://127.0.0.1:0", "")
+	if _, err := client.GetUnimportedEntries(context.Background()); err == nil {
+		t.Error("expected error for missing token")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -26,13 +27,97 @@ func SetupCommands(a *App) *cobra.Command {
 			return sheets, cobra.ShellCompDirectiveNoFileComp
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			name := args[0]
+			if err := a.ChangeSheet(args[0]); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	sheetCompletion := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		sheets, err := a.repo.GetAllSheets()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return sheets, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// sheet management subcommands
+	var listAll bool
+	sheetListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List sheets with entry counts, total time and last use",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.ListSheets(listAll); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	sheetListCmd.Flags().BoolVarP(&listAll, "all", "a", false, "Include archived sheets")
+
+	sheetRenameCmd := &cobra.Command{
+		Use:               "rename [name] [new name]",
+		Short:             "Rename sheet",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: sheetCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.RenameSheet(args[0], args[1]); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
 
-			a.ChangeSheet(name)
+	var deleteReassign string
+	var deleteYes bool
+	sheetDeleteCmd := &cobra.Command{
+		Use:               "delete [name]",
+		Short:             "Delete sheet with its entries or move entries to another sheet",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: sheetCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.DeleteSheet(args[0], deleteReassign, deleteYes); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	sheetDeleteCmd.Flags().StringVar(&deleteReassign, "reassign", "", "Move entries to this sheet instead of deleting them")
+	sheetDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Do not ask for confirmation")
+
+	sheetArchiveCmd := &cobra.Command{
+		Use:               "archive [name]",
+		Short:             "Hide sheet from completion and display, keeping its entries",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: sheetCompletion,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.ArchiveSheet(args[0], true); err != nil {
+				fmt.Println(err)
+			}
 		},
 	}
 
+	sheetUnarchiveCmd := &cobra.Command{
+		Use:   "unarchive [name]",
+		Short: "Restore archived sheet",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.ArchiveSheet(args[0], false); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	sheetCmd.AddCommand(sheetListCmd)
+	sheetCmd.AddCommand(sheetRenameCmd)
+	sheetCmd.AddCommand(sheetDeleteCmd)
+	sheetCmd.AddCommand(sheetArchiveCmd)
+	sheetCmd.AddCommand(sheetUnarchiveCmd)
+
 	// command for start time tracking
+	var startAt string
+	var startTags []string
 	startCmd := &cobra.Command{
 		Use:   "start [note]",
 		Short: "Start tracking time",
@@ -42,11 +127,16 @@ func SetupCommands(a *App) *cobra.Command {
 				note = args[0]
 			}
 
-			a.StartTracking(note)
+			if err := a.StartTracking(note, startAt, startTags); err != nil {
+				fmt.Println(err)
+			}
 		},
 	}
+	startCmd.Flags().StringVar(&startAt, "at", "", "Start time (e.g. \"09:15\", \"2026-10-15 17:30\", \"-10m\", \"15 minutes ago\")")
+	startCmd.Flags().StringSliceVar(&startTags, "tag", nil, "Tag entry (repeatable), #hashtags in note are added too")
 
 	// command for stop time tracking
+	var stopAt, stopSheet string
 	stopCmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop tracking time",
@@ -56,46 +146,329 @@ func SetupCommands(a *App) *cobra.Command {
 				note = args[0]
 			}
 
-			a.StopTracking(note)
+			if err := a.StopTracking(note, stopAt, stopSheet); err != nil {
+				fmt.Println(err)
+			}
 		},
 	}
+	stopCmd.Flags().StringVar(&stopSheet, "sheet", "", "Stop running entry of this sheet instead of active sheet")
+	stopCmd.Flags().StringVar(&stopAt, "at", "", "End time (e.g. \"17:30\", \"-5m\", \"5 minutes ago\")")
 
+	var displayOpts DisplayOptions
 	displayCmd := &cobra.Command{
 		Use:       "display [period]",
 		Short:     "Display all entries in period or specific sheet",
 		ValidArgs: []string{"day", "week", "month", "year"},
 		Run: func(cmd *cobra.Command, args []string) {
-			period := "day"
+			displayOpts.Type = "day"
+			if len(args) > 0 {
+				displayOpts.Type = args[0]
+			}
+
+			if err := a.Display(displayOpts); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	addRangeFlags(displayCmd, &displayOpts)
+	displayCmd.Flags().StringVar(&displayOpts.Format, "format", "table", "Output format (table, json, csv, tsv)")
+
+	// command for exporting entries, selects range same way as display
+	var exportOpts ExportOptions
+	exportCmd := &cobra.Command{
+		Use:       "export [period]",
+		Short:     "Export entries in period to file (e.g. calendar)",
+		ValidArgs: []string{"day", "week", "month", "year"},
+		Run: func(cmd *cobra.Command, args []string) {
+			exportOpts.Type = "day"
 			if len(args) > 0 {
-				period = args[0]
+				exportOpts.Type = args[0]
+			}
+
+			if err := a.Export(exportOpts); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	addRangeFlags(exportCmd, &exportOpts.DisplayOptions)
+	exportCmd.Flags().StringVar(&exportOpts.Format, "format", "ics", "Export format (ics, timeclock, json, csv, tsv)")
+	exportCmd.Flags().StringVarP(&exportOpts.Output, "output", "o", "", "Write export to file instead of stdout")
+	exportCmd.Flags().StringVar(&exportOpts.AccountPrefix, "account-prefix", "", "Prefix of timeclock account names (e.g. \"work:\")")
+
+	// command for backfilling finished entry
+	var addSheet string
+	var addTags []string
+	addCmd := &cobra.Command{
+		Use:   "add [start] [end|duration] [note]",
+		Short: "Add finished entry (e.g. add 9:00 11:30 \"code review\", add \"yesterday 14:00\" 1h30m)",
+		Long: `Add finished entry (e.g. add 9:00 11:30 "code review", add "yesterday 14:00" 1h30m).
+
+End is a time or a duration from start ("1h30m", "+45m"). Signed offsets such
+as "-10m" are times relative to now, put them after "--" so they are not read
+as flags: add -- -2h -10m "code review".`,
+		Args: cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var note string
+			if len(args) > 2 {
+				note = args[2]
 			}
 
-			a.Display(period)
+			if err := a.AddEntry(args[0], args[1], note, addSheet, addTags); err != nil {
+				fmt.Println(err)
+			}
 		},
 	}
+	addCmd.Flags().StringVar(&addSheet, "sheet", "", "Sheet of entry (defaults to active sheet)")
+	addCmd.Flags().StringSliceVar(&addTags, "tag", nil, "Tag entry (repeatable), #hashtags in note are added too")
 
+	// command for stopping running entry and starting on another sheet at once
+	var switchAt string
+	switchCmd := &cobra.Command{
+		Use:   "switch [sheet] [note]",
+		Short: "Stop running entry and start tracking on another sheet",
+		Args:  cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			sheets, err := a.repo.GetAllSheets()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return sheets, cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var note string
+			if len(args) > 1 {
+				note = args[1]
+			}
+
+			if err := a.Switch(args[0], note, switchAt); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	switchCmd.Flags().StringVar(&switchAt, "at", "", "Switch time (e.g. \"13:00\", \"-5m\")")
+
+	// command for starting new entry with sheet and note of previous one
+	var resumePick bool
+	var resumeAt string
+	resumeCmd := &cobra.Command{
+		Use:   "resume [id]",
+		Short: "Start tracking with sheet and note of last or chosen entry",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var id int64
+			if len(args) > 0 {
+				parsed, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					fmt.Printf("Invalid entry id: %s\n", args[0])
+					return
+				}
+				id = parsed
+			}
+
+			if err := a.Resume(id, resumePick, resumeAt); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	resumeCmd.Flags().BoolVarP(&resumePick, "pick", "p", false, "Pick from recent notes")
+	resumeCmd.Flags().StringVar(&resumeAt, "at", "", "Start time (e.g. \"13:00\", \"-5m\")")
+
+	// command for showing running entry and today's total
+	var statusFormat string
+	statusCmd := &cobra.Command{
+		Use:     "status",
+		Aliases: []string{"now"},
+		Short:   "Show active sheet, running entry and today's total",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.Status(statusFormat); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	statusCmd.Flags().StringVar(&statusFormat, "format", "", "Go template (e.g. '{{if .Running}}{{.RunningSheet}} {{.Elapsed}}{{end}}'), fields: Sheet, Running, RunningSheet, Note, StartTime, Elapsed, ElapsedSeconds, Today, TodaySeconds")
+
+	// command for editing start, end, note and sheet of existing entry
+	var editStart, editEnd, editNote, editSheet string
+	editCmd := &cobra.Command{
+		Use:   "edit [id]",
+		Short: "Edit entry (defaults to last entry)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var id int64
+			if len(args) > 0 {
+				parsed, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					fmt.Printf("Invalid entry id: %s\n", args[0])
+					return
+				}
+				id = parsed
+			}
+
+			// only apply flags which were explicitly set
+			var opts EditOptions
+			if cmd.Flags().Changed("start") {
+				opts.Start = &editStart
+			}
+			if cmd.Flags().Changed("end") {
+				opts.End = &editEnd
+			}
+			if cmd.Flags().Changed("note") {
+				opts.Note = &editNote
+			}
+			if cmd.Flags().Changed("sheet") {
+				opts.Sheet = &editSheet
+			}
+
+			if err := a.EditEntry(id, opts); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	editCmd.Flags().StringVar(&editStart, "start", "", "New start time")
+	editCmd.Flags().StringVar(&editEnd, "end", "", "New end time")
+	editCmd.Flags().StringVar(&editNote, "note", "", "New note")
+	editCmd.Flags().StringVar(&editSheet, "sheet", "", "Move entry to sheet")
+
+	var importSince, importFrom string
+	var csvOpts CSVImportOptions
+	var telegramOpts TelegramImportOptions
 	importCmd := &cobra.Command{
-		Use:   "import [url]",
-		Short: "Import trackings from external sources (Telegram BOT)",
+		Use:   "import [source|path]",
+		Short: "Import trackings from external sources (Telegram BOT, timetrap, csv)",
 		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if importFrom != "telegram" {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			return a.config.ImportSourceNames(), cobra.ShellCompDirectiveNoFileComp
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			url := args[0]
+			var msg string
+			var err error
 
-			msg, err := a.Import(url)
+			switch importFrom {
+			case "telegram":
+				telegramOpts.Since = importSince
+				msg, err = a.Import(cmd.Context(), args[0], telegramOpts)
+			case "timetrap":
+				msg, err = a.ImportTimetrap(args[0], importSince)
+			case "csv":
+				msg, err = a.ImportCSV(args[0], csvOpts)
+			default:
+				err = fmt.Errorf("Invalid import source: %s", importFrom)
+			}
 			if err != nil {
 				fmt.Println(err)
+				return
 			}
 
 			fmt.Println(msg)
 		},
 	}
+	importCmd.Flags().StringVar(&importFrom, "from", "telegram", "Import source (telegram, timetrap, csv)")
+	importCmd.Flags().StringVar(&telegramOpts.RulesPath, "rules", "", "Rules file mapping notes to sheets (defaults to ~/.config/timetick/rules.json)")
+	importCmd.Flags().StringVar(&telegramOpts.DefaultSheet, "default-sheet", "", "Sheet for entries not matching any rule")
+	importCmd.Flags().BoolVar(&telegramOpts.NonInteractive, "non-interactive", false, "Import entries matching rules or default sheet without preview")
+	importCmd.Flags().StringVar(&csvOpts.SheetColumn, "sheet-column", "", "CSV column with sheet name")
+	importCmd.Flags().StringVar(&csvOpts.Sheet, "sheet", "", "CSV import sheet used when there is no sheet column")
+	importCmd.Flags().StringVar(&csvOpts.StartColumn, "start-column", "start", "CSV column with start time (join columns with +)")
+	importCmd.Flags().StringVar(&csvOpts.EndColumn, "end-column", "", "CSV column with end time")
+	importCmd.Flags().StringVar(&csvOpts.DurationColumn, "duration-column", "", "CSV column with duration, used when there is no end column")
+	importCmd.Flags().StringVar(&csvOpts.NoteColumn, "note-column", "", "CSV column with note")
+	importCmd.Flags().StringVar(&csvOpts.DateFormat, "date-format", "", "CSV time layout in go format (e.g. \"02/01/2006 15:04\")")
+	importCmd.Flags().StringVar(&csvOpts.Delimiter, "delimiter", ",", "CSV field delimiter")
+	importCmd.Flags().BoolVar(&csvOpts.DryRun, "dry-run", false, "Print CSV entries without importing them")
+
+	importCmd.Flags().StringVar(&importSince, "since", "", "Only import entries started after this time (e.g. \"last monday\")")
+
+	// commands for maintaining database
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "Manage database",
+	}
+
+	var migrateStatus bool
+	dbMigrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Only report schema version, migrations run automatically when database is opened",
+		Long: `Only report schema version of database, this command does not migrate anything.
+
+Pending migrations are applied automatically by every command when it opens
+the database, so database is always up to date when this command runs.
+Use --status to list all migrations with time they were applied at.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := a.Migrate(migrateStatus); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	dbMigrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "Show applied and pending migrations")
+
+	dbBackupCmd := &cobra.Command{
+		Use:   "backup [path]",
+		Short: "Back up database (defaults to backup directory next to database)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			if err := a.Backup(cmd.Context(), path); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	var restoreYes bool
+	dbRestoreCmd := &cobra.Command{
+		Use:   "restore [path]",
+		Short: "Replace database with backup (picked from backup directory without path)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			if err := a.Restore(cmd.Context(), path, restoreYes); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+	dbRestoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "Do not ask for confirmation")
+
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbBackupCmd)
+	dbCmd.AddCommand(dbRestoreCmd)
 
 	// add commands
 	rootCmd.AddCommand(sheetCmd)
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(switchCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(displayCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(dbCmd)
 
 	return rootCmd
 }
+
+// adds flags selecting time range and tags of entries shared by display and export
+func addRangeFlags(cmd *cobra.Command, opts *DisplayOptions) {
+	cmd.Flags().StringVar(&opts.Date, "date", "", "Use period containing this date (e.g. \"yesterday\", \"last monday\")")
+	cmd.Flags().IntVar(&opts.Offset, "offset", 0, "Move period by N periods (e.g. -1 for previous week)")
+	cmd.Flags().StringVar(&opts.Start, "start", "", "Select entries from this time (overrides period)")
+	cmd.Flags().StringVar(&opts.End, "end", "", "Select entries until this time (defaults to now)")
+	cmd.Flags().StringSliceVar(&opts.Tags, "tag", nil, "Select only entries with this tag (repeatable, entries must have all tags)")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// hour at which day starts ("04:00"), entries before it belong to previous day
+	DayStart string `json:"day_start"`
+	// path of rules file mapping imported entry notes to sheets
+	ImportRules string `json:"import_rules"`
+	// number of retries of failed requests to import API
+	ImportRetries int `json:"import_retries"`
+	// what start does when entry is already running: "refuse" or "stop" it
+	StartWhenRunning string `json:"start_when_running"`
+	// allow one running entry per sheet instead of one in total
+	TimerPerSheet bool `json:"timer_per_sheet"`
+	// named telegram bot instances used by import command
+	ImportSources map[string]ImportSource `json:"import_sources"`
+	// IANA zone ("Europe/Belgrade") times are displayed and parsed in, local zone if empty
+	Timezone string `json:"timezone"`
+}
+
+// telegram bot instance, token is taken from first set of token,
+// token_env (environment variable name) and token_command (e.g. password manager)
+type ImportSource struct {
+	URL          string `json:"url"`
+	Token        string `json:"token,omitempty"`
+	TokenEnv     string `json:"token_env,omitempty"`
+	TokenCommand string `json:"token_command,omitempty"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		DayStart:         "00:00",
+		StartWhenRunning: "refuse",
+		ImportRetries:    3,
+	}
+}
+
+// loads config from json file, missing file results in default config
+func LoadConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if _, err := cfg.DayStartOffset(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.Location(); err != nil {
+		return nil, err
+	}
+	if cfg.StartWhenRunning != "refuse" && cfg.StartWhenRunning != "stop" {
+		return nil, fmt.Errorf("invalid start_when_running '%s', expected refuse or stop", cfg.StartWhenRunning)
+	}
+
+	return cfg, nil
+}
+
+// returns day start as offset from midnight
+func (c *Config) DayStartOffset() (time.Duration, error) {
+	if c.DayStart == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse("15:04", c.DayStart)
+	if err != nil {
+		return 0, fmt.Errorf("invalid day_start '%s', expected HH:MM", c.DayStart)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// returns zone times are displayed and parsed in, stored times are always UTC
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
+// resolves import source by name, raw url is accepted for backward
+// compatibility and uses token from API_TOKEN environment variable
+func (c *Config) ResolveImportSource(name string) (ImportSource, error) {
+	if source, ok := c.ImportSources[name]; ok {
+		if source.URL == "" {
+			return source, fmt.Errorf("import source %s has no url", name)
+		}
+		return source, nil
+	}
+
+	if strings.Contains(name, "://") {
+		return ImportSource{URL: name, TokenEnv: "API_TOKEN"}, nil
+	}
+
+	return ImportSource{}, fmt.Errorf("unknown import source: %s (add it to import_sources in config)", name)
+}
+
+// returns api token of source
+func (s ImportSource) ResolveToken() (string, error) {
+	if s.Token != "" {
+		return s.Token, nil
+	}
+
+	if s.TokenEnv != "" {
+		if token := os.Getenv(s.TokenEnv); token != "" {
+			return token, nil
+		}
+		if s.TokenCommand == "" {
+			return "", fmt.Errorf("missing API token: environment variable %s is not set", s.TokenEnv)
+		}
+	}
+
+	if s.TokenCommand != "" {
+		var cmd *exec.Cmd
+		switch runtime.GOOS {
+		case "windows":
+			cmd = exec.Command("cmd", "/c", s.TokenCommand)
+		default: // for unix based systems
+			cmd = exec.Command("sh", "-c", s.TokenCommand)
+		}
+		cmd.Stderr = os.Stderr
+
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to run token_command: %w", err)
+		}
+
+		// password managers print secret on first line
+		token, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		if token == "" {
+			return "", fmt.Errorf("missing API token: token_command printed nothing")
+		}
+		return strings.TrimSpace(token), nil
+	}
+
+	return "", fmt.Errorf("missing API token: set token, token_env or token_command of import source")
+}
+
+// returns sorted names of configured import sources
+func (c *Config) ImportSourceNames() []string {
+	names := make([]string, 0, len(c.ImportSources))
+	for name := range c.ImportSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reads entries from csv file using column mapping, entries are grouped
+// into sheets in order of first appearance, whole file is parsed before
+// anything is returned so invalid rows never result in partial import
+func ReadCSVEntries(path string, opts CSVImportOptions, parser *TimeParser) ([]Sheet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open csv file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if opts.Delimiter != "" {
+		reader.Comma = []rune(opts.Delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	sheetCols, err := resolveColumns(opts.SheetColumn, columns)
+	if err != nil {
+		return nil, err
+	}
+	startCols, err := resolveColumns(opts.StartColumn, columns)
+	if err != nil {
+		return nil, err
+	}
+	endCols, err := resolveColumns(opts.EndColumn, columns)
+	if err != nil {
+		return nil, err
+	}
+	durationCols, err := resolveColumns(opts.DurationColumn, columns)
+	if err != nil {
+		return nil, err
+	}
+	noteCols, err := resolveColumns(opts.NoteColumn, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if startCols == nil {
+		return nil, fmt.Errorf("start column is required")
+	}
+	if endCols == nil && durationCols == nil {
+		return nil, fmt.Errorf("end or duration column is required")
+	}
+	if sheetCols == nil && opts.Sheet == "" {
+		return nil, fmt.Errorf("sheet column or sheet name is required")
+	}
+
+	var sheets []Sheet
+	sheetIndex := make(map[string]int)
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		sheetName := opts.Sheet
+		if sheetCols != nil {
+			sheetName = joinColumns(record, sheetCols)
+		}
+		if sheetName == "" {
+			return nil, fmt.Errorf("line %d: empty sheet", line)
+		}
+
+		entry := Entry{Note: joinColumns(record, noteCols)}
+
+		entry.StartTime, err = parseCSVTime(joinColumns(record, startCols), opts.DateFormat, parser)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if endCols != nil {
+			entry.EndTime, err = parseCSVTime(joinColumns(record, endCols), opts.DateFormat, parser)
+		} else {
+			var duration time.Duration
+			duration, err = parseCSVDuration(joinColumns(record, durationCols))
+			entry.EndTime = entry.StartTime.Add(duration)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if !entry.EndTime.After(entry.StartTime) {
+			return nil, fmt.Errorf("line %d: end time must be after start time", line)
+		}
+
+		i, exists := sheetIndex[sheetName]
+		if !exists {
+			i = len(sheets)
+			sheetIndex[sheetName] = i
+			sheets = append(sheets, Sheet{Name: sheetName})
+		}
+		sheets[i].Entries = append(sheets[i].Entries, entry)
+	}
+
+	return sheets, nil
+}
+
+// resolves column spec into column indexes, spec is header name or 1-based
+// index, several columns can be joined with "+" (e.g. "Start date+Start time")
+func resolveColumns(spec string, columns map[string]int) ([]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	var indexes []int
+	for _, part := range strings.Split(spec, "+") {
+		name := strings.ToLower(strings.TrimSpace(part))
+
+		if i, ok := columns[name]; ok {
+			indexes = append(indexes, i)
+			continue
+		}
+		if n, err := strconv.Atoi(name); err == nil && n > 0 {
+			indexes = append(indexes, n-1)
+			continue
+		}
+
+		return nil, fmt.Errorf("unknown csv column: %s", part)
+	}
+
+	return indexes, nil
+}
+
+// joins values of columns with space
+func joinColumns(record []string, indexes []int) string {
+	var values []string
+	for _, i := range indexes {
+		if i < len(record) {
+			values = append(values, strings.TrimSpace(record[i]))
+		}
+	}
+	return strings.TrimSpace(strings.Join(values, " "))
+}
+
+// parses time using go layout if provided, otherwise as time expression
+func parseCSVTime(value string, layout string, parser *TimeParser) (time.Time, error) {
+	if layout == "" {
+		return parser.Parse(value)
+	}
+
+	t, err := time.ParseInLocation(layout, value, parser.Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s' for format '%s'", value, layout)
+	}
+	return t, nil
+}
+
+// parses duration in "H:MM:SS", "H:MM", go ("1h30m") or decimal hours ("1.5") format
+func parseCSVDuration(value string) (time.Duration, error) {
+	if parts := strings.Split(value, ":"); len(parts) == 2 || len(parts) == 3 {
+		var total time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			total += time.Duration(n) * units[i]
+		}
+		return total, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, nil
+	}
+
+	if hours, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(hours * float64(time.Hour)), nil
+	}
+
+	return 0, fmt.Errorf("invalid duration: %s", value)
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -13,6 +14,17 @@ import (
 
 const (
 	// migration quries
+	createSchemaMigrationsTableSQL = `
+  CREATE TABLE IF NOT EXISTS schema_migrations (
+  version INTEGER PRIMARY KEY,
+  name TEXT NOT NULL,
+  applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+  )`
+	getSchemaVersionSQL      = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`
+	getSchemaMigrationsSQL   = `SELECT version, name, applied_at FROM schema_migrations ORDER BY version`
+	insertSchemaMigrationSQL = `INSERT INTO schema_migrations (version, name) VALUES (?, ?)`
+	checkTableExistsSQL      = `SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)`
+
 	createSheetsTableSQL = `
   CREATE TABLE IF NOT EXISTS sheets (
   id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -32,43 +44,127 @@ const (
   FOREIGN KEY (sheet_id) REFERENCES sheets(id)
   )`
 
+	createTagsTableSQL = `
+  CREATE TABLE IF NOT EXISTS tags (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE
+  )`
+
+	createEntryTagsTableSQL = `
+  CREATE TABLE IF NOT EXISTS entry_tags (
+  entry_id INTEGER NOT NULL,
+  tag_id INTEGER NOT NULL,
+  PRIMARY KEY (entry_id, tag_id),
+  FOREIGN KEY (entry_id) REFERENCES entries(id),
+  FOREIGN KEY (tag_id) REFERENCES tags(id)
+  )`
+
+	// columns added after initial release, created on existing databases
+	addEntriesSourceColumnSQL   = `ALTER TABLE entries ADD COLUMN source TEXT`
+	addEntriesSourceIDColumnSQL = `ALTER TABLE entries ADD COLUMN source_id INTEGER`
+	addSheetsArchivedColumnSQL  = `ALTER TABLE sheets ADD COLUMN archived INTEGER NOT NULL DEFAULT 0`
+	// imported entries are unique per source, local entries have NULL source
+	createEntriesSourceIndexSQL = `CREATE UNIQUE INDEX IF NOT EXISTS entries_source_idx ON entries (source, source_id)`
+
 	// sheet queries
 	createSheetSQL          = `INSERT INTO sheets (name) VALUES (?)`
-	getAllSheetsSQL         = `SELECT name FROM sheets`
+	createSheetIfMissingSQL = `INSERT OR IGNORE INTO sheets (name) VALUES (?)`
+	getAllSheetsSQL         = `SELECT name FROM sheets WHERE archived = 0 ORDER BY name`
 	getSheetIdByNameSQL     = `SELECT id FROM sheets WHERE name = ?`
 	getActiveSheetIdSQL     = `SELECT id FROM sheets WHERE active = 1`
+	getActiveSheetSQL       = `SELECT id, name FROM sheets WHERE active = 1`
+	getSheetNameByIdSQL     = `SELECT name FROM sheets WHERE id = ?`
 	getSheetsWithEntriesSQL = `
-  SELECT s.name, e.start_time, e.end_time, e.note
+  SELECT s.name, e.id, e.start_time, e.end_time, e.note, e.created_at,
+  (SELECT GROUP_CONCAT(t.name) FROM entry_tags et JOIN tags t ON t.id = et.tag_id WHERE et.entry_id = e.id)
   FROM sheets s
   JOIN entries e ON e.sheet_id = s.id
-  WHERE e.start_time >= ? AND e.start_time <= ? AND e.end_time IS NOT NULL
+  WHERE e.start_time >= ? AND e.start_time < ? AND e.end_time IS NOT NULL AND s.archived = 0
+  ORDER BY s.name, e.start_time
+  `
+	getSheetsWithStatsSQL = `
+  SELECT s.id, s.name, s.active, s.archived, e.start_time, e.end_time
+  FROM sheets s
+  LEFT JOIN entries e ON e.sheet_id = s.id
   ORDER BY s.name
   `
-	checkSheetExistsSQL    = `SELECT EXISTS(SELECT 1 FROM sheets WHERE name = ?)`
-	activateSheetByNameSQL = `UPDATE sheets SET active = 1 WHERE name = ?`
-	deactivateAllSheetsSQL = `UPDATE sheets SET active = 0`
+	renameSheetSQL          = `UPDATE sheets SET name = ? WHERE name = ?`
+	setSheetArchivedSQL     = `UPDATE sheets SET archived = ?, active = CASE WHEN ? THEN 0 ELSE active END WHERE name = ?`
+	deleteSheetSQL          = `DELETE FROM sheets WHERE id = ?`
+	deleteSheetEntriesSQL   = `DELETE FROM entries WHERE sheet_id = ?`
+	deleteSheetEntryTagsSQL = `DELETE FROM entry_tags WHERE entry_id IN (SELECT id FROM entries WHERE sheet_id = ?)`
+	reassignSheetEntriesSQL = `UPDATE entries SET sheet_id = ? WHERE sheet_id = ?`
+	countSheetEntriesSQL    = `SELECT COUNT(*) FROM entries WHERE sheet_id = ?`
+	checkSheetExistsSQL     = `SELECT EXISTS(SELECT 1 FROM sheets WHERE name = ?)`
+	isSheetArchivedSQL      = `SELECT archived FROM sheets WHERE name = ?`
+	activateSheetByNameSQL  = `UPDATE sheets SET active = 1 WHERE name = ?`
+	deactivateAllSheetsSQL  = `UPDATE sheets SET active = 0`
+	transferActiveSheetSQL  = `UPDATE sheets SET active = 1 WHERE id = ? AND EXISTS(SELECT 1 FROM sheets WHERE id = ? AND active = 1)`
 
 	// entry queries
-	createEntrySQL               = `INSERT INTO entries (sheet_id, start_time, note) VALUES (?, ?, ?)`
-	createFullEntrySQL           = `INSERT INTO entries(sheet_id, start_time, end_time, note) VALUES (?, ?, ?, ?)`
-	getTrackingEntrySQL          = `SELECT id, note FROM entries WHERE end_time IS NULL`
-	checkEntryHasNoteSQL         = `SELECT note FROM entries WHERE end_time IS NULL LIMIT 1`
+	createEntrySQL          = `INSERT INTO entries (sheet_id, start_time, note) VALUES (?, ?, ?)`
+	createFullEntrySQL      = `INSERT INTO entries(sheet_id, start_time, end_time, note) VALUES (?, ?, ?, ?)`
+	checkEntryExistsSQL     = `SELECT EXISTS(SELECT 1 FROM entries WHERE sheet_id = ? AND start_time = ?)`
+	getRunningEntrySQL      = `SELECT id, sheet_id, start_time, end_time, note, created_at FROM entries WHERE end_time IS NULL ORDER BY start_time DESC LIMIT 1`
+	getLastFinishedEntrySQL = `SELECT id, sheet_id, start_time, end_time, note, created_at FROM entries WHERE end_time IS NOT NULL ORDER BY end_time DESC, id DESC LIMIT 1`
+	getRecentNotesSQL       = `
+  SELECT s.name, e.note,
+  (SELECT l.id FROM entries l WHERE l.sheet_id = s.id AND l.note = e.note ORDER BY l.start_time DESC LIMIT 1)
+  FROM entries e
+  JOIN sheets s ON s.id = e.sheet_id
+  WHERE e.note IS NOT NULL AND e.note != '' AND s.archived = 0
+  GROUP BY s.name, e.note
+  ORDER BY MAX(e.start_time) DESC
+  LIMIT ?
+  `
+	getRunningSheetEntrySQL      = `SELECT id, sheet_id, start_time, end_time, note, created_at FROM entries WHERE end_time IS NULL AND sheet_id = ? ORDER BY start_time DESC LIMIT 1`
 	updateEntryEndTimeAndNoteSQL = `UPDATE entries SET end_time = ?, note = ? WHERE id = ?`
+	getEntryByIDSQL              = `SELECT id, sheet_id, start_time, end_time, note, created_at FROM entries WHERE id = ?`
+	getLastEntrySQL              = `SELECT id, sheet_id, start_time, end_time, note, created_at FROM entries ORDER BY start_time DESC, id DESC LIMIT 1`
+	updateEntrySQL               = `UPDATE entries SET sheet_id = ?, start_time = ?, end_time = ?, note = ? WHERE id = ?`
+	createImportedEntrySQL       = `
+  INSERT OR IGNORE INTO entries (sheet_id, start_time, end_time, note, source, source_id)
+  VALUES (?, ?, ?, ?, ?, ?)
+  `
+	getOverlappingEntriesSQL = `
+  SELECT id, sheet_id, start_time, end_time, note, created_at
+  FROM entries
+  WHERE sheet_id = ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)
+  ORDER BY start_time
+  `
+	checkSourceEntryExistsSQL = `SELECT EXISTS(SELECT 1 FROM entries WHERE source = ? AND source_id = ?)`
+	getEntryTimesSQL          = `SELECT id, start_time, end_time FROM entries`
+	updateEntryTimesSQL       = `UPDATE entries SET start_time = ?, end_time = ? WHERE id = ?`
+
+	// tag queries
+	createTagIfMissingSQL = `INSERT OR IGNORE INTO tags (name) VALUES (?)`
+	getTagIdByNameSQL     = `SELECT id FROM tags WHERE name = ?`
+	addEntryTagSQL        = `INSERT OR IGNORE INTO entry_tags (entry_id, tag_id) VALUES (?, ?)`
+	deleteEntryTagsSQL    = `DELETE FROM entry_tags WHERE entry_id = ?`
+	getEntryTagsSQL       = `
+  SELECT t.name FROM entry_tags et
+  JOIN tags t ON t.id = et.tag_id
+  WHERE et.entry_id = ?
+  ORDER BY t.name
+  `
 )
 
 type Repo struct {
 	db *sql.DB
+	// directory of manual and automatic backups
+	backupDir string
 }
 
-func NewRepo(dbPath string) (*Repo, error) {
+// opens database, timestamps read from it are converted to location
+func NewRepo(dbPath string, location *time.Location) (*Repo, error) {
 	// ensure directory exists
 	err := os.MkdirAll(filepath.Dir(dbPath), os.ModePerm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// open database
-	db, err := sql.Open("sqlite3", dbPath)
+	// open database, driver converts read timestamps to location
+	db, err := sql.Open("sqlite3", dbPath+"?_loc="+url.QueryEscape(location.String()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -79,7 +175,10 @@ func NewRepo(dbPath string) (*Repo, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	repo := &Repo{db: db}
+	repo := &Repo{
+		db:        db,
+		backupDir: filepath.Join(filepath.Dir(dbPath), "backups"),
+	}
 
 	// run migrations
 	if err := repo.runMigrations(); err != nil {
@@ -94,22 +193,6 @@ func (r *Repo) Close() error {
 	return r.db.Close()
 }
 
-// runs migrations on initial start
-func (r *Repo) runMigrations() error {
-	tables := []string{
-		createSheetsTableSQL,
-		createEntriesTableSQL,
-	}
-
-	for _, tableSQL := range tables {
-		if _, err := r.db.Exec(tableSQL); err != nil {
-			return fmt.Errorf("failed to create table: %w", err)
-		}
-	}
-
-	return nil
-}
-
 // +---------------------+
 // |                     |
 // |    Sheet Queries    |
@@ -209,36 +292,225 @@ func (r *Repo) GetActiveSheetID() (int64, error) {
 	return id, nil
 }
 
+// get active sheet (without entries), nil if no sheet is active
+func (r *Repo) GetActiveSheet() (*Sheet, error) {
+	sheet := Sheet{Active: true}
+
+	err := r.db.QueryRow(getActiveSheetSQL).Scan(&sheet.ID, &sheet.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting active sheet: %w", err)
+	}
+
+	return &sheet, nil
+}
+
+// gets sheet name by provided id
+func (r *Repo) GetSheetNameByID(id int64) (string, error) {
+	var name string
+
+	err := r.db.QueryRow(getSheetNameByIdSQL, id).Scan(&name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("no sheet found with id: %d", id)
+		}
+		return "", fmt.Errorf("error getting sheet name: %w", err)
+	}
+
+	return name, nil
+}
+
+// get all sheets (archived included) with number of entries, total tracked
+// time of finished entries and start of last entry
+func (r *Repo) GetSheetsWithStats() ([]SheetStats, error) {
+	rows, err := r.db.Query(getSheetsWithStatsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// keep sheets in query order
+	var sheets []SheetStats
+	sheetIndex := make(map[int64]int)
+
+	for rows.Next() {
+		var sheet Sheet
+		var startTime, endTime sql.NullTime
+
+		if err := rows.Scan(&sheet.ID, &sheet.Name, &sheet.Active, &sheet.Archived, &startTime, &endTime); err != nil {
+			return nil, err
+		}
+
+		i, exists := sheetIndex[sheet.ID]
+		if !exists {
+			i = len(sheets)
+			sheetIndex[sheet.ID] = i
+			sheets = append(sheets, SheetStats{Sheet: sheet})
+		}
+
+		// sheet without entries
+		if !startTime.Valid {
+			continue
+		}
+
+		stats := &sheets[i]
+		stats.EntryCount++
+		if endTime.Valid {
+			stats.Total += endTime.Time.Sub(startTime.Time)
+		}
+		if startTime.Time.After(stats.LastUsed) {
+			stats.LastUsed = startTime.Time
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sheets, nil
+}
+
+// renames sheet, new name must not be used by other sheet
+func (r *Repo) RenameSheet(oldName string, newName string) error {
+	res, err := r.db.Exec(renameSheetSQL, newName, oldName)
+	if err != nil {
+		return fmt.Errorf("error renaming sheet: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no sheet found with name: %s", oldName)
+	}
+
+	return nil
+}
+
+// reports whether sheet is archived, missing sheet is not archived
+func (r *Repo) IsSheetArchived(name string) (bool, error) {
+	var archived bool
+
+	err := r.db.QueryRow(isSheetArchivedSQL, name).Scan(&archived)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("error checking sheet: %w", err)
+	}
+
+	return archived, nil
+}
+
+// archives or unarchives sheet, archived sheet is no longer active
+func (r *Repo) SetSheetArchived(name string, archived bool) error {
+	res, err := r.db.Exec(setSheetArchivedSQL, archived, archived, name)
+	if err != nil {
+		return fmt.Errorf("error archiving sheet: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no sheet found with name: %s", name)
+	}
+
+	return nil
+}
+
+// counts entries of sheet
+func (r *Repo) CountSheetEntries(sheetID int64) (int, error) {
+	var count int
+	if err := r.db.QueryRow(countSheetEntriesSQL, sheetID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting entries: %w", err)
+	}
+	return count, nil
+}
+
+// deletes sheet in single transaction, its entries are moved to reassignTo
+// sheet (created if missing, active if deleted sheet was) or deleted when
+// reassignTo is empty
+func (r *Repo) DeleteSheet(sheetID int64, reassignTo string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reassignTo != "" {
+		if _, err := tx.Exec(createSheetIfMissingSQL, reassignTo); err != nil {
+			return fmt.Errorf("error creating sheet %s: %w", reassignTo, err)
+		}
+
+		var targetID int64
+		if err := tx.QueryRow(getSheetIdByNameSQL, reassignTo).Scan(&targetID); err != nil {
+			return fmt.Errorf("error getting sheet id: %w", err)
+		}
+		if targetID == sheetID {
+			return fmt.Errorf("cannot reassign entries to deleted sheet")
+		}
+
+		if _, err := tx.Exec(reassignSheetEntriesSQL, targetID, sheetID); err != nil {
+			return fmt.Errorf("error reassigning entries: %w", err)
+		}
+		if _, err := tx.Exec(transferActiveSheetSQL, targetID, sheetID); err != nil {
+			return err
+		}
+	} else {
+		if _, err := tx.Exec(deleteSheetEntryTagsSQL, sheetID); err != nil {
+			return fmt.Errorf("error deleting entry tags: %w", err)
+		}
+		if _, err := tx.Exec(deleteSheetEntriesSQL, sheetID); err != nil {
+			return fmt.Errorf("error deleting entries: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(deleteSheetSQL, sheetID); err != nil {
+		return fmt.Errorf("error deleting sheet: %w", err)
+	}
+
+	return tx.Commit()
+}
+
 // get all sheets with their entries
 func (r *Repo) GetSheetsWithEntries(startTime, endTime time.Time) ([]Sheet, error) {
-	rows, err := r.db.Query(getSheetsWithEntriesSQL, startTime, endTime)
+	rows, err := r.db.Query(getSheetsWithEntriesSQL, startTime.UTC(), endTime.UTC())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	sheetMap := make(map[string]*Sheet)
+	// keep sheets in query order
+	var sheets []Sheet
+	sheetIndex := make(map[string]int)
 
 	for rows.Next() {
 		var sheetName string
 		var entry Entry
+		var tags sql.NullString
 
-		if err := rows.Scan(&sheetName, &entry.StartTime, &entry.EndTime, &entry.Note); err != nil {
+		if err := rows.Scan(&sheetName, &entry.ID, &entry.StartTime, &entry.EndTime, &entry.Note, &entry.CreatedAt, &tags); err != nil {
 			return nil, err
 		}
+		entry.Tags = splitTags(tags.String)
 
-		sheet, exists := sheetMap[sheetName]
+		i, exists := sheetIndex[sheetName]
 		if !exists {
-			sheet = &Sheet{Name: sheetName}
-			sheetMap[sheetName] = sheet
+			i = len(sheets)
+			sheetIndex[sheetName] = i
+			sheets = append(sheets, Sheet{Name: sheetName})
 		}
 
-		sheet.Entries = append(sheet.Entries, entry)
+		sheets[i].Entries = append(sheets[i].Entries, entry)
 	}
 
-	sheets := make([]Sheet, 0, len(sheetMap))
-	for _, sheet := range sheetMap {
-		sheets = append(sheets, *sheet)
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return sheets, nil
@@ -249,55 +521,390 @@ func (r *Repo) GetSheetsWithEntries(startTime, endTime time.Time) ([]Sheet, erro
 // |    Entry Queries    |
 // |                     |
 // +---------------------+
-func (r *Repo) CreateEntry(sheetID int64, startTime time.Time, note string) error {
-	_, err := r.db.Exec(createEntrySQL, sheetID, startTime, note)
-	return err
-}
+// stops running entry (if not nil) and starts new entry on sheet at the
+// same time in single transaction
+func (r *Repo) StartEntry(running *Entry, sheetID int64, at time.Time, note string, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := stopAndStartEntry(tx, running, sheetID, at, note, tags); err != nil {
+		return err
+	}
 
-func (r *Repo) HasActiveEntryNote() bool {
-	var note string
+	return tx.Commit()
+}
 
-	err := r.db.QueryRow(checkEntryHasNoteSQL).Scan(&note)
+// stops running entry (if not nil) and creates entry with tags in transaction
+func stopAndStartEntry(tx *sql.Tx, running *Entry, sheetID int64, at time.Time, note string, tags []string) error {
+	if running != nil {
+		if _, err := tx.Exec(updateEntryEndTimeAndNoteSQL, at.UTC(), running.Note, running.ID); err != nil {
+			return fmt.Errorf("error while stopping running entry: %w", err)
+		}
+	}
 
+	res, err := tx.Exec(createEntrySQL, sheetID, at.UTC(), note)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return false
-		}
-		return false
+		return fmt.Errorf("error while creating entry: %w", err)
 	}
+	return addEntryTags(tx, res, tags)
+}
 
-	return note != ""
+// checks if sheet already has entry started at given time
+func (r *Repo) CheckEntryExists(sheetID int64, startTime time.Time) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(checkEntryExistsSQL, sheetID, startTime.UTC()).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking if entry exists: %w", err)
+	}
+	return exists, nil
 }
 
 // creates full entry in database (used for importing from telegram bot)
-func (r *Repo) CreateFullEntry(sheetName string, startTime time.Time, endTime sql.NullTime, note string) error {
+func (r *Repo) CreateFullEntry(sheetName string, startTime time.Time, endTime sql.NullTime, note string, tags []string) error {
 	sheetId, err := r.GetSheetIdByName(sheetName)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Exec(createFullEntrySQL, sheetId, startTime, endTime, note)
-	return err
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(createFullEntrySQL, sheetId, startTime.UTC(), utcNullTime(endTime), note)
+	if err != nil {
+		return err
+	}
+	if err := addEntryTags(tx, res, tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *Repo) UpdateEntry(endTime time.Time, note string) error {
-	var entryID int64
-	var existingNote string
+// links tags (created if missing) to entry inserted by res
+func addEntryTags(tx *sql.Tx, res sql.Result, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
 
-	err := r.db.QueryRow(getTrackingEntrySQL).Scan(&entryID, &existingNote)
+	entryID, err := res.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("error finding entry without end time: %w", err)
+		return err
+	}
+
+	return tagEntry(tx, entryID, tags)
+}
+
+// links tags (created if missing) to entry
+func tagEntry(tx *sql.Tx, entryID int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.Exec(createTagIfMissingSQL, tag); err != nil {
+			return fmt.Errorf("error creating tag %s: %w", tag, err)
+		}
+
+		var tagID int64
+		if err := tx.QueryRow(getTagIdByNameSQL, tag).Scan(&tagID); err != nil {
+			return fmt.Errorf("error getting tag id: %w", err)
+		}
+
+		if _, err := tx.Exec(addEntryTagSQL, entryID, tagID); err != nil {
+			return fmt.Errorf("error tagging entry: %w", err)
+		}
 	}
 
-	// use provided note param if the existing note is empty
-	updateNote := existingNote
-	if existingNote == "" && note != "" {
+	return nil
+}
+
+// sets end time of running entry, note is only set if entry has none
+func (r *Repo) StopEntry(entry *Entry, endTime time.Time, note string) error {
+	updateNote := entry.Note
+	if updateNote == "" && note != "" {
 		updateNote = note
 	}
 
-	_, err = r.db.Exec(updateEntryEndTimeAndNoteSQL, endTime, updateNote, entryID)
+	_, err := r.db.Exec(updateEntryEndTimeAndNoteSQL, endTime.UTC(), updateNote, entry.ID)
 	if err != nil {
 		return fmt.Errorf("error while updating end time to entry: %w", err)
 	}
 
 	return nil
 }
+
+// gets entry by id
+func (r *Repo) GetEntryByID(id int64) (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(getEntryByIDSQL, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no entry found with id: %d", id)
+		}
+		return nil, fmt.Errorf("error getting entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// gets the most recently started entry
+func (r *Repo) GetLastEntry() (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(getLastEntrySQL))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no entries found")
+		}
+		return nil, fmt.Errorf("error getting last entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// gets most recently finished entry
+func (r *Repo) GetLastFinishedEntry() (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(getLastFinishedEntrySQL))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no finished entries found")
+		}
+		return nil, fmt.Errorf("error getting last finished entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// gets distinct sheet and note pairs, most recently used first
+func (r *Repo) GetRecentNotes(limit int) ([]RecentNote, error) {
+	rows, err := r.db.Query(getRecentNotesSQL, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []RecentNote
+	for rows.Next() {
+		var note RecentNote
+		if err := rows.Scan(&note.Sheet, &note.Note, &note.EntryID); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+// gets most recently started entry which is currently tracked (has no end time),
+// nil if none is running
+func (r *Repo) GetRunningEntry() (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(getRunningEntrySQL))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting running entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// gets entry currently tracked on sheet, nil if none is running
+func (r *Repo) GetRunningSheetEntry(sheetID int64) (*Entry, error) {
+	entry, err := scanEntry(r.db.QueryRow(getRunningSheetEntrySQL, sheetID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting running entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// overwrites sheet, start time, end time and note of existing entry,
+// zero end time is stored as NULL (entry is still running)
+func (r *Repo) EditEntry(entry *Entry) error {
+	endTime := sql.NullTime{Time: entry.EndTime, Valid: !entry.EndTime.IsZero()}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(updateEntrySQL, entry.SheetID, entry.StartTime.UTC(), utcNullTime(endTime), entry.Note, entry.ID)
+	if err != nil {
+		return fmt.Errorf("error while updating entry: %w", err)
+	}
+
+	// tags are replaced with tags of edited entry
+	if _, err := tx.Exec(deleteEntryTagsSQL, entry.ID); err != nil {
+		return err
+	}
+	if err := tagEntry(tx, entry.ID, entry.Tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// gets tags of entry sorted by name
+func (r *Repo) GetEntryTags(entryID int64) ([]string, error) {
+	rows, err := r.db.Query(getEntryTagsSQL, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// gets entries of sheet which overlap with range, running entries overlap
+// everything after their start
+func (r *Repo) GetOverlappingEntries(sheetID int64, startTime, endTime time.Time) ([]Entry, error) {
+	rows, err := r.db.Query(getOverlappingEntriesSQL, sheetID, endTime.UTC(), startTime.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// timestamps are stored in UTC so they compare correctly as text in queries
+func utcNullTime(t sql.NullTime) sql.NullTime {
+	if t.Valid {
+		t.Time = t.Time.UTC()
+	}
+	return t
+}
+
+// scans single entry row (from *sql.Row or *sql.Rows), running entries get zero end time
+func scanEntry(row interface{ Scan(dest ...any) error }) (*Entry, error) {
+	var entry Entry
+	var endTime sql.NullTime
+	var note sql.NullString
+
+	if err := row.Scan(&entry.ID, &entry.SheetID, &entry.StartTime, &endTime, &note, &entry.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	entry.EndTime = endTime.Time
+	entry.Note = note.String
+
+	return &entry, nil
+}
+
+// checks if entry from external source was already imported
+func (r *Repo) CheckSourceEntryExists(source string, sourceID int64) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRow(checkSourceEntryExistsSQL, source, sourceID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error checking if imported entry exists: %w", err)
+	}
+	return exists, nil
+}
+
+// creates entries imported from external source in single transaction,
+// missing sheets are created and already imported entries are ignored,
+// returns number of created entries
+func (r *Repo) CreateImportedEntries(entries []ImportedEntry) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sheetIDs := make(map[string]int64)
+	var created int
+
+	for _, entry := range entries {
+		sheetID, ok := sheetIDs[entry.Sheet]
+		if !ok {
+			if _, err := tx.Exec(createSheetIfMissingSQL, entry.Sheet); err != nil {
+				return 0, fmt.Errorf("error creating sheet %s: %w", entry.Sheet, err)
+			}
+			if err := tx.QueryRow(getSheetIdByNameSQL, entry.Sheet).Scan(&sheetID); err != nil {
+				return 0, fmt.Errorf("error getting sheet id: %w", err)
+			}
+			sheetIDs[entry.Sheet] = sheetID
+		}
+
+		res, err := tx.Exec(createImportedEntrySQL, sheetID, entry.StartTime.UTC(), utcNullTime(entry.EndTime), entry.Note, entry.Source, entry.SourceID)
+		if err != nil {
+			return 0, fmt.Errorf("error creating imported entry %d: %w", entry.SourceID, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		created += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// stops running entry (if not nil) and starts new entry on sheet at the same time
+// in single transaction, sheet is created if missing and becomes active
+func (r *Repo) SwitchEntry(running *Entry, sheetName string, at time.Time, note string, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(createSheetIfMissingSQL, sheetName); err != nil {
+		return fmt.Errorf("error creating sheet %s: %w", sheetName, err)
+	}
+
+	var sheetID int64
+	if err := tx.QueryRow(getSheetIdByNameSQL, sheetName).Scan(&sheetID); err != nil {
+		return fmt.Errorf("error getting sheet id: %w", err)
+	}
+
+	if _, err := tx.Exec(deactivateAllSheetsSQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(activateSheetByNameSQL, sheetName); err != nil {
+		return err
+	}
+
+	if err := stopAndStartEntry(tx, running, sheetID, at, note, tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// returns formatter used by export command, besides display formats
+// it supports calendar export ("ics") and hledger timeclock ("timeclock")
+func NewExportFormatter(opts ExportOptions, dayStart time.Duration) (Formatter, error) {
+	switch opts.Format {
+	case "ics":
+		return &ICSFormatter{}, nil
+	case "timeclock":
+		return &TimeclockFormatter{AccountPrefix: opts.AccountPrefix}, nil
+	}
+
+	return NewFormatter(opts.Format, dayStart)
+}
+
+// +-------------------+
+// |                   |
+// |    iCalendar      |
+// |                   |
+// +-------------------+
+
+const icsTimeLayout = "20060102T150405Z"
+
+// writes every entry as VEVENT, uid is derived from entry id so
+// importing same export again updates events instead of duplicating them
+type ICSFormatter struct{}
+
+func (f *ICSFormatter) Format(w io.Writer, sheets []Sheet) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//timetick//timetick//EN",
+		"CALSCALE:GREGORIAN",
+	}
+
+	for _, sheet := range sheets {
+		for _, entry := range sheet.Entries {
+			summary := sheet.Name
+			if entry.Note != "" {
+				summary = fmt.Sprintf("%s: %s", sheet.Name, entry.Note)
+			}
+
+			// stamp must not change between exports
+			stamp := entry.CreatedAt
+			if stamp.IsZero() {
+				stamp = entry.StartTime
+			}
+
+			lines = append(lines,
+				"BEGIN:VEVENT",
+				"UID:entry-"+strconv.FormatInt(entry.ID, 10)+"@timetick",
+				"DTSTAMP:"+stamp.UTC().Format(icsTimeLayout),
+				"DTSTART:"+entry.StartTime.UTC().Format(icsTimeLayout),
+				"DTEND:"+entry.EndTime.UTC().Format(icsTimeLayout),
+				"SUMMARY:"+escapeICSText(summary),
+			)
+
+			// tags are listed as additional categories
+			categories := []string{escapeICSText(sheet.Name)}
+			for _, tag := range entry.Tags {
+				categories = append(categories, escapeICSText(tag))
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+
+			if entry.Note != "" {
+				lines = append(lines, "DESCRIPTION:"+escapeICSText(entry.Note))
+			}
+			lines = append(lines, "END:VEVENT")
+		}
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldICSLine(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// escapes TEXT value as defined in RFC 5545
+func escapeICSText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// splits line longer than 75 octets into continuation lines starting with space,
+// without breaking multi-byte characters
+func foldICSLine(line string) string {
+	const limit = 75
+
+	var b strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > limit {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+
+	return b.String()
+}
+
+// +-------------------+
+// |                   |
+// |    Timeclock      |
+// |                   |
+// +-------------------+
+
+const timeclockTimeLayout = "2006-01-02 15:04:05"
+
+// writes entries as ledger/hledger timeclock "i" and "o" lines,
+// sheet name (with optional prefix) is used as account
+type TimeclockFormatter struct {
+	AccountPrefix string
+}
+
+func (f *TimeclockFormatter) Format(w io.Writer, sheets []Sheet) error {
+	type clockEntry struct {
+		account string
+		entry   Entry
+	}
+
+	var entries []clockEntry
+	for _, sheet := range sheets {
+		for _, entry := range sheet.Entries {
+			entries = append(entries, clockEntry{account: timeclockAccount(f.AccountPrefix + sheet.Name), entry: entry})
+		}
+	}
+
+	// timeclock files are read top to bottom, so sessions must be chronological
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].entry.StartTime.Before(entries[j].entry.StartTime)
+	})
+
+	for _, e := range entries {
+		in := fmt.Sprintf("i %s %s", e.entry.StartTime.Format(timeclockTimeLayout), e.account)
+		if note := strings.Join(strings.Fields(e.entry.Note), " "); note != "" {
+			// two spaces separate account from description
+			in += "  " + note
+		}
+
+		if _, err := fmt.Fprintf(w, "%s\no %s\n\n", in, e.entry.EndTime.Format(timeclockTimeLayout)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// account names end at two spaces or tab, so whitespace runs are collapsed
+func timeclockAccount(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// renders sheets with their entries in specific output format
+type Formatter interface {
+	Format(w io.Writer, sheets []Sheet) error
+}
+
+// returns formatter for format name ("table", "json", "csv", "tsv")
+func NewFormatter(format string, dayStart time.Duration) (Formatter, error) {
+	switch format {
+	case "", "table":
+		return &TableFormatter{DayStart: dayStart}, nil
+	case "json":
+		return &JSONFormatter{}, nil
+	case "csv":
+		return &CSVFormatter{Comma: ','}, nil
+	case "tsv":
+		return &CSVFormatter{Comma: '\t'}, nil
+	}
+
+	return nil, fmt.Errorf("Invalid format: %s", format)
+}
+
+// +-------------+
+// |             |
+// |    Table    |
+// |             |
+// +-------------+
+
+// human readable table per sheet, entries are grouped by day
+type TableFormatter struct {
+	DayStart time.Duration
+}
+
+func (f *TableFormatter) Format(w io.Writer, sheets []Sheet) error {
+	for _, sheet := range sheets {
+		fmt.Fprintf(w, "Sheet - %s\n", sheet.Name)
+
+		headers := []string{"ID", "Day", "Start", "End", "Duration", "Notes"}
+
+		var rows [][]string
+		totalDuration := time.Duration(0)
+
+		var lastDay string
+		for _, entry := range sheet.Entries {
+			day := LogicalDay(entry.StartTime, f.DayStart).Format("Jan 02, 2006")
+			startTime := entry.StartTime.Format("15:04:05")
+			endTime := entry.EndTime.Format("15:04:05")
+			duration := entry.EndTime.Sub(entry.StartTime)
+			totalDuration += duration
+
+			// print day only on first entry of the day
+			dayCell := ""
+			if day != lastDay {
+				dayCell = day
+				lastDay = day
+			}
+
+			// entries which are not stored yet (e.g. dry run) have no id
+			id := ""
+			if entry.ID != 0 {
+				id = strconv.FormatInt(entry.ID, 10)
+			}
+
+			rows = append(rows, []string{
+				id,
+				dayCell,
+				startTime,
+				endTime,
+				FormatDuration(duration),
+				entry.Note,
+			})
+		}
+
+		footers := []string{"", "", "", "Total:", FormatDuration(totalDuration), ""}
+		PrintTable(w, headers, rows, footers)
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w)
+	}
+
+	// entry with multiple tags counts towards each of them
+	if totals := TagTotals(sheets); len(totals) > 0 {
+		fmt.Fprintln(w, "Tags")
+
+		var rows [][]string
+		for _, total := range totals {
+			rows = append(rows, []string{"#" + total.Tag, strconv.Itoa(total.Entries), FormatDuration(total.Total)})
+		}
+		PrintTable(w, []string{"Tag", "Entries", "Duration"}, rows, nil)
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// +------------+
+// |            |
+// |    JSON    |
+// |            |
+// +------------+
+
+type (
+	jsonEntry struct {
+		ID              int64     `json:"id"`
+		Sheet           string    `json:"sheet"`
+		Start           time.Time `json:"start"`
+		End             time.Time `json:"end"`
+		DurationSeconds int64     `json:"duration_seconds"`
+		Note            string    `json:"note"`
+		Tags            []string  `json:"tags"`
+	}
+
+	jsonSheet struct {
+		Name         string      `json:"name"`
+		TotalSeconds int64       `json:"total_seconds"`
+		Entries      []jsonEntry `json:"entries"`
+	}
+
+	jsonTag struct {
+		Name         string `json:"name"`
+		Entries      int    `json:"entries"`
+		TotalSeconds int64  `json:"total_seconds"`
+	}
+
+	jsonOutput struct {
+		TotalSeconds int64       `json:"total_seconds"`
+		Sheets       []jsonSheet `json:"sheets"`
+		Tags         []jsonTag   `json:"tags"`
+	}
+)
+
+// sheets with entries and per sheet totals as single json document
+type JSONFormatter struct{}
+
+func (f *JSONFormatter) Format(w io.Writer, sheets []Sheet) error {
+	output := jsonOutput{Sheets: []jsonSheet{}, Tags: []jsonTag{}}
+
+	for _, sheet := range sheets {
+		js := jsonSheet{Name: sheet.Name, Entries: []jsonEntry{}}
+
+		for _, entry := range sheet.Entries {
+			duration := int64(entry.EndTime.Sub(entry.StartTime).Seconds())
+			js.TotalSeconds += duration
+
+			tags := entry.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+
+			js.Entries = append(js.Entries, jsonEntry{
+				ID:              entry.ID,
+				Sheet:           sheet.Name,
+				Start:           entry.StartTime,
+				End:             entry.EndTime,
+				DurationSeconds: duration,
+				Note:            entry.Note,
+				Tags:            tags,
+			})
+		}
+
+		output.TotalSeconds += js.TotalSeconds
+		output.Sheets = append(output.Sheets, js)
+	}
+
+	for _, total := range TagTotals(sheets) {
+		output.Tags = append(output.Tags, jsonTag{
+			Name:         total.Tag,
+			Entries:      total.Entries,
+			TotalSeconds: int64(total.Total.Seconds()),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// +-----------------+
+// |                 |
+// |    CSV / TSV    |
+// |                 |
+// +-----------------+
+
+// one row per entry with header, fields containing separator are quoted,
+// tags are joined with space
+type CSVFormatter struct {
+	Comma rune
+}
+
+func (f *CSVFormatter) Format(w io.Writer, sheets []Sheet) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = f.Comma
+
+	if err := writer.Write([]string{"id", "sheet", "start", "end", "duration_seconds", "note", "tags"}); err != nil {
+		return err
+	}
+
+	for _, sheet := range sheets {
+		for _, entry := range sheet.Entries {
+			duration := int64(entry.EndTime.Sub(entry.StartTime).Seconds())
+
+			record := []string{
+				strconv.FormatInt(entry.ID, 10),
+				sheet.Name,
+				entry.StartTime.Format(time.RFC3339),
+				entry.EndTime.Format(time.RFC3339),
+				strconv.FormatInt(duration, 10),
+				entry.Note,
+				strings.Join(entry.Tags, " "),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
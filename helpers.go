@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -13,7 +16,7 @@ import (
 // |    Table    |
 // |             |
 // +-------------+
-func PrintTable(headers []string, rows [][]string, footers []string) {
+func PrintTable(w io.Writer, headers []string, rows [][]string, footers []string) {
 	colWidths := make([]int, len(headers))
 
 	// calc initial column width using header width
@@ -31,28 +34,28 @@ func PrintTable(headers []string, rows [][]string, footers []string) {
 
 	// print header
 	for i, header := range headers {
-		fmt.Fprintf(os.Stdout, "%-*s\t", colWidths[i], header)
+		fmt.Fprintf(w, "%-*s\t", colWidths[i], header)
 	}
-	fmt.Fprintln(os.Stdout)
+	fmt.Fprintln(w)
 
 	// print rows
 	for _, row := range rows {
 		for i, cell := range row {
-			fmt.Fprintf(os.Stdout, "%-*s\t", colWidths[i], cell)
+			fmt.Fprintf(w, "%-*s\t", colWidths[i], cell)
 		}
-		fmt.Fprintln(os.Stdout)
+		fmt.Fprintln(w)
 	}
 
 	// print footer
 	for i, footer := range footers {
 		if footer != "" {
-			fmt.Fprintf(os.Stdout, "%-*s\t", colWidths[i], footer)
+			fmt.Fprintf(w, "%-*s\t", colWidths[i], footer)
 		} else {
 			// print empty space for skipped footer
-			fmt.Fprintf(os.Stdout, "%-*s\t", colWidths[i], "")
+			fmt.Fprintf(w, "%-*s\t", colWidths[i], "")
 		}
 	}
-	fmt.Fprintln(os.Stdout)
+	fmt.Fprintln(w)
 }
 
 // converts duration value into a formatted string
@@ -65,6 +68,15 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
 }
 
+// asks yes/no question on stdin, anything but "y" or "yes" is no
+func confirm(question string) bool {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // clears terminal screen
 func clearScreen() {
 	var cmd *exec.Cmd
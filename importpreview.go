@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nexidian/gocliselect"
+)
+
+// how sheet of import row was assigned
+const (
+	assignedNone = iota
+	assignedRule
+	assignedDefault
+	assignedManual
+)
+
+// entry fetched from telegram bot together with decisions made
+// about it before import is confirmed
+type importRow struct {
+	entry    APIEntry
+	sheet    string
+	note     string
+	endTime  time.Time // zero while entry is still running
+	assigned int
+	rule     int // index of matched rule when assigned by rule
+	dropped  bool
+}
+
+func (r *importRow) running() bool {
+	return r.endTime.IsZero()
+}
+
+const importPreviewHelp = `Commands:
+  a <rows> [sheet]   assign sheet to rows, without sheet pick it from menu (e.g. "a 1-3,5 acme", "a * acme")
+  n <row> <note>     change note of row
+  e <row> <time>     set end time of running row (e.g. "e 4 17:30")
+  d <rows>           drop rows, they are marked as imported without being stored
+  u <rows>           undo drop of rows
+  c                  confirm and import rows with sheet, rows without sheet stay on remote
+  q                  quit without importing
+Rows are numbers from first column, "*" selects all rows.`
+
+// shows preview of all fetched entries and lets user assign sheets, edit
+// notes and drop rows before confirming, returns false if user quit
+func (a *App) runImportPreview(rows []*importRow, in io.Reader) (bool, error) {
+	reader := bufio.NewReader(in)
+
+	printImportPreview(os.Stdout, rows)
+	fmt.Println(importPreviewHelp)
+
+	for {
+		fmt.Print("> ")
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+
+		command, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+		args = strings.TrimSpace(args)
+
+		switch command {
+		case "":
+			continue
+		case "a":
+			spec, sheet, _ := strings.Cut(args, " ")
+			selected, err := parseRowSelection(spec, len(rows))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+
+			sheet = strings.TrimSpace(sheet)
+			if sheet == "" {
+				sheet, err = a.pickSheet(fmt.Sprintf("Sheet for rows %s", spec))
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+				if sheet == "" {
+					continue
+				}
+			}
+
+			for _, i := range selected {
+				rows[i].sheet = sheet
+				rows[i].assigned = assignedManual
+			}
+		case "n":
+			spec, note, _ := strings.Cut(args, " ")
+			i, err := parseRowNumber(spec, len(rows))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			rows[i].note = strings.TrimSpace(note)
+		case "e":
+			spec, value, _ := strings.Cut(args, " ")
+			i, err := parseRowNumber(spec, len(rows))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+
+			endTime, err := a.timeParser.Parse(value)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			if !endTime.After(rows[i].entry.StartTime) {
+				fmt.Println("End time must be after start time")
+				continue
+			}
+			rows[i].endTime = endTime
+		case "d", "u":
+			selected, err := parseRowSelection(args, len(rows))
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			for _, i := range selected {
+				rows[i].dropped = command == "d"
+			}
+		case "c":
+			// running entries are never imported open-ended
+			var pending []string
+			for i, row := range rows {
+				if !row.dropped && row.sheet != "" && row.running() {
+					pending = append(pending, strconv.Itoa(i+1))
+				}
+			}
+			if len(pending) > 0 {
+				fmt.Printf("Rows %s are still running, set end time with 'e' or drop them with 'd'\n", strings.Join(pending, ", "))
+				continue
+			}
+			return true, nil
+		case "q":
+			return false, nil
+		case "h", "?", "help":
+			fmt.Println(importPreviewHelp)
+			continue
+		default:
+			fmt.Printf("Unknown command: %s (type 'h' for help)\n", command)
+			continue
+		}
+
+		printImportPreview(os.Stdout, rows)
+	}
+}
+
+// lets user pick one of existing sheets, returns empty name if skipped
+func (a *App) pickSheet(title string) (string, error) {
+	sheets, err := a.repo.GetAllSheets()
+	if err != nil {
+		return "", err
+	}
+	if len(sheets) == 0 {
+		return "", fmt.Errorf("No sheets exist yet, pass sheet name to create one")
+	}
+
+	menu := gocliselect.NewMenu(title)
+	for _, sheet := range sheets {
+		menu.AddItem(sheet, sheet)
+	}
+	menu.EnableSkip("cancel")
+
+	selected, _ := menu.Display()
+	if selected == nil {
+		return "", nil
+	}
+
+	return selected.(string), nil
+}
+
+// prints fetched entries with their current sheet and state
+func printImportPreview(w io.Writer, rows []*importRow) {
+	headers := []string{"#", "ID", "Start", "End", "Duration", "Sheet", "Note"}
+
+	var tableRows [][]string
+	var total time.Duration
+
+	for i, row := range rows {
+		end := "running"
+		duration := ""
+		if !row.running() {
+			end = row.endTime.Format("2006-01-02 15:04")
+			d := row.endTime.Sub(row.entry.StartTime)
+			duration = FormatDuration(d)
+			if !row.dropped {
+				total += d
+			}
+		}
+
+		sheet := row.sheet
+		if row.dropped {
+			sheet = "(dropped)"
+		} else if sheet == "" {
+			sheet = "-"
+		}
+
+		tableRows = append(tableRows, []string{
+			strconv.Itoa(i + 1),
+			strconv.Itoa(row.entry.ID),
+			row.entry.StartTime.Format("2006-01-02 15:04"),
+			end,
+			duration,
+			sheet,
+			row.note,
+		})
+	}
+
+	footers := []string{"", "", "", "Total:", FormatDuration(total), "", ""}
+	PrintTable(w, headers, tableRows, footers)
+}
+
+// parses row selection like "1-3,5" or "*" into zero-based indexes
+func parseRowSelection(spec string, count int) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("No rows selected")
+	}
+
+	if spec == "*" || spec == "all" {
+		indexes := make([]int, count)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	var indexes []int
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, err := parseRowNumber(from, count)
+		if err != nil {
+			return nil, err
+		}
+		last, err := parseRowNumber(to, count)
+		if err != nil {
+			return nil, err
+		}
+		if last < first {
+			return nil, fmt.Errorf("Invalid row range: %s", part)
+		}
+
+		for i := first; i <= last; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
+
+// parses one-based row number into zero-based index
+func parseRowNumber(value string, count int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 || n > count {
+		return 0, fmt.Errorf("Invalid row: %s", value)
+	}
+	return n - 1, nil
+}
@@ -1,37 +1,71 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"time"
 )
 
 type App struct {
-	repo *Repo
+	repo       *Repo
+	config     *Config
+	timeParser *TimeParser
 }
 
-func NewApp(repo *Repo) *App {
+func NewApp(repo *Repo, config *Config, location *time.Location) *App {
+	timeParser := NewTimeParser()
+	timeParser.Location = location
+
 	return &App{
-		repo: repo,
+		repo:       repo,
+		config:     config,
+		timeParser: timeParser,
 	}
 }
 
 func main() {
+	// load config
+	configDir := filepath.Join(os.Getenv("HOME"), ".config", "timetick")
+
+	config, err := LoadConfig(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if config.ImportRules == "" {
+		config.ImportRules = filepath.Join(configDir, "rules.json")
+	}
+
+	// times are stored in UTC and converted to this zone on read
+	location, err := config.Location()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	// initilize repo
 	dbPath := filepath.Join(os.Getenv("HOME"), ".local", "share", "timetick", "database.db")
 
-	repo, err := NewRepo(dbPath)
+	repo, err := NewRepo(dbPath, location)
 	if err != nil {
-		fmt.Errorf("failed to create repository: %v", err)
+		fmt.Printf("failed to create repository: %v\n", err)
+		os.Exit(1)
 	}
 	defer repo.Close()
 
 	// initilize app
-	app := NewApp(repo)
+	app := NewApp(repo, config, location)
 
 	cmd := SetupCommands(app)
 
-	if err := cmd.Execute(); err != nil {
+	// cancel requests in progress on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.ExecuteContext(ctx); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
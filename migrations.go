@@ -0,0 +1,233 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// numbered schema change, applied once in its own transaction
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// schema migrations in order of version, applied migrations must never be
+// changed, new schema changes are appended with next version. steps are
+// idempotent because databases created before versioning already have
+// some of them applied
+var migrations = []migration{
+	{1, "create sheets and entries", execMigration(createSheetsTableSQL, createEntriesTableSQL)},
+	{2, "add entry source", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "entries", "source", addEntriesSourceColumnSQL); err != nil {
+			return err
+		}
+		if err := addColumnIfMissing(tx, "entries", "source_id", addEntriesSourceIDColumnSQL); err != nil {
+			return err
+		}
+		_, err := tx.Exec(createEntriesSourceIndexSQL)
+		return err
+	}},
+	{3, "add sheet archived", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "sheets", "archived", addSheetsArchivedColumnSQL)
+	}},
+	{4, "create tags", execMigration(createTagsTableSQL, createEntryTagsTableSQL)},
+	{5, "store entry times in UTC", normalizeEntryTimes},
+}
+
+// returns version of newest migration known to this binary
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// returns migration step executing statements in order
+func execMigration(statements ...string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// applies pending migrations, database migrated by newer binary is refused
+// so older binary never writes to schema it does not know
+func (r *Repo) runMigrations() error {
+	if _, err := r.db.Exec(createSchemaMigrationsTableSQL); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	version, err := r.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > latestSchemaVersion() {
+		return fmt.Errorf("database schema version %d is newer than version %d supported by this timetick, upgrade timetick", version, latestSchemaVersion())
+	}
+
+	if version == latestSchemaVersion() {
+		return nil
+	}
+
+	// existing data is backed up before its schema is changed
+	exists, err := tableExists(r.db, "entries")
+	if err != nil {
+		return err
+	}
+	if exists {
+		if _, err := r.AutoBackup("migrate"); err != nil {
+			return fmt.Errorf("failed to back up database before migration: %w", err)
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := r.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// runs migration and records it in single transaction
+func (r *Repo) applyMigration(m migration) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(insertSchemaMigrationSQL, m.version, m.name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// returns version of newest applied migration, 0 for new database
+func (r *Repo) SchemaVersion() (int, error) {
+	var version int
+	if err := r.db.QueryRow(getSchemaVersionSQL).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// returns all known migrations with time they were applied at
+func (r *Repo) GetMigrationStatus() ([]MigrationStatus, error) {
+	rows, err := r.db.Query(getSchemaMigrationsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationStatus)
+	for rows.Next() {
+		var status MigrationStatus
+		if err := rows.Scan(&status.Version, &status.Name, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		status, ok := applied[m.version]
+		if !ok {
+			status = MigrationStatus{Version: m.version, Name: m.name}
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// rewrites entry times stored with local offset in UTC, older versions
+// stored times in zone of value so text comparison of them was wrong
+func normalizeEntryTimes(tx *sql.Tx) error {
+	type entryTimes struct {
+		id        int64
+		startTime sql.NullTime
+		endTime   sql.NullTime
+	}
+
+	rows, err := tx.Query(getEntryTimesSQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var entries []entryTimes
+	for rows.Next() {
+		var entry entryTimes
+		if err := rows.Scan(&entry.id, &entry.startTime, &entry.endTime); err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, entry := range entries {
+		// unparsable start time is left as it is
+		if !entry.startTime.Valid || entry.startTime.Time.IsZero() {
+			continue
+		}
+
+		if _, err := tx.Exec(updateEntryTimesSQL, entry.startTime.Time.UTC(), utcNullTime(entry.endTime), entry.id); err != nil {
+			return fmt.Errorf("failed to update entry %d: %w", entry.id, err)
+		}
+	}
+
+	return nil
+}
+
+// adds column to table unless it already exists
+func addColumnIfMissing(tx *sql.Tx, table string, column string, columnSQL string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read table info: %w", err)
+	}
+	defer rows.Close()
+
+	exists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if exists {
+		return nil
+	}
+
+	if _, err := tx.Exec(columnSQL); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// creates database at path migrated up to version
+func createDatabaseAtVersion(t *testing.T, path string, version int) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(createSchemaMigrationsTableSQL); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &Repo{db: db}
+	for _, m := range migrations {
+		if m.version > version {
+			break
+		}
+		if err := repo.applyMigration(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNormalizeEntryTimesToUTC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timetick.db")
+	createDatabaseAtVersion(t, path, 4)
+
+	// times stored with local offset by versions before migration 5
+	createDatabase(t, path,
+		`INSERT INTO sheets (name) VALUES ('work')`,
+		`INSERT INTO entries (sheet_id, start_time, end_time, note) VALUES (1, '2026-10-15 09:00:00+02:00', '2026-10-15 10:30:00+02:00', 'finished')`,
+		`INSERT INTO entries (sheet_id, start_time, end_time, note) VALUES (1, '2026-10-15 23:30:00-05:00', NULL, 'running')`,
+		`INSERT INTO entries (sheet_id, start_time, end_time, note) VALUES (1, '2026-10-15 07:00:00+00:00', '2026-10-15 08:00:00+00:00', 'utc')`,
+	)
+
+	repo, err := NewRepo(path, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+
+	// raw text as stored, concatenation skips driver time parsing
+	rows, err := repo.db.Query(`SELECT note, start_time || '', COALESCE(end_time || '', '') FROM entries ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	want := map[string][2]string{
+		"finished": {"2026-10-15 07:00:00+00:00", "2026-10-15 08:30:00+00:00"},
+		"running":  {"2026-10-16 04:30:00+00:00", ""},
+		"utc":      {"2026-10-15 07:00:00+00:00", "2026-10-15 08:00:00+00:00"},
+	}
+	for rows.Next() {
+		var note, start, end string
+		if err := rows.Scan(&note, &start, &end); err != nil {
+			t.Fatal(err)
+		}
+		if got := [2]string{start, end}; got != want[note] {
+			t.Errorf("%s: stored %q, want %q", note, got, want[note])
+		}
+		delete(want, note)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing entries %v", want)
+	}
+
+	version, err := repo.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("schema version %d, want %d", version, latestSchemaVersion())
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timetick.db")
+	createDatabaseAtVersion(t, path, latestSchemaVersion())
+	createDatabase(t, path, fmt.Sprintf(`INSERT INTO schema_migrations (version, name) VALUES (%d, 'from future')`, latestSchemaVersion()+1))
+
+	repo, err := NewRepo(path, time.UTC)
+	if err == nil {
+		repo.Close()
+		t.Fatal("expected error for database migrated by newer version")
+	}
+	if !strings.Contains(err.Error(), "newer") {
+		t.Errorf("got error %v, want newer schema refusal", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// returns [start, end) range of named period ("day", "week", "month", "year")
+// containing ref, moved by offset periods. Boundaries are computed on calendar
+// dates in ref location, so days spanning DST changes are 23 or 25 hours long.
+// Days start at dayStart after midnight, weeks start on Monday.
+func PeriodRange(period string, ref time.Time, offset int, dayStart time.Duration) (time.Time, time.Time, error) {
+	day := LogicalDay(ref, dayStart)
+	year, month, date := day.Date()
+
+	var start, end time.Time
+
+	switch period {
+	case "day":
+		start = dayStartAt(year, month, date+offset, dayStart, ref.Location())
+		end = dayStartAt(year, month, date+offset+1, dayStart, ref.Location())
+	case "week":
+		// days since monday
+		sinceMonday := (int(day.Weekday()) + 6) % 7
+		monday := date - sinceMonday + 7*offset
+		start = dayStartAt(year, month, monday, dayStart, ref.Location())
+		end = dayStartAt(year, month, monday+7, dayStart, ref.Location())
+	case "month":
+		start = dayStartAt(year, month+time.Month(offset), 1, dayStart, ref.Location())
+		end = dayStartAt(year, month+time.Month(offset)+1, 1, dayStart, ref.Location())
+	case "year":
+		start = dayStartAt(year+offset, time.January, 1, dayStart, ref.Location())
+		end = dayStartAt(year+offset+1, time.January, 1, dayStart, ref.Location())
+	default:
+		return start, end, fmt.Errorf("Invalid display mode: %s", period)
+	}
+
+	return start, end, nil
+}
+
+// returns midnight of calendar day t belongs to, times before dayStart
+// belong to previous day
+func LogicalDay(t time.Time, dayStart time.Duration) time.Time {
+	year, month, date := t.Date()
+	midnight := time.Date(year, month, date, 0, 0, 0, 0, t.Location())
+
+	if t.Before(dayStartAt(year, month, date, dayStart, t.Location())) {
+		return time.Date(year, month, date-1, 0, 0, 0, 0, t.Location())
+	}
+
+	return midnight
+}
+
+// returns length of part of [start, end) which falls into [from, to)
+func ClipDuration(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// returns wall clock dayStart on given date, out of range dates are normalized
+func dayStartAt(year int, month time.Month, date int, dayStart time.Duration, loc *time.Location) time.Time {
+	hours := int(dayStart / time.Hour)
+	minutes := int((dayStart % time.Hour) / time.Minute)
+
+	return time.Date(year, month, date, hours, minutes, 0, 0, loc)
+}
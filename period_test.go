@@ -0,0 +1,150 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodRange(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name      string
+		period    string
+		ref       time.Time
+		offset    int
+		dayStart  time.Duration
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"day", "day", date(2026, 10, 15, 10), 0, 0, date(2026, 10, 15, 0), date(2026, 10, 16, 0)},
+		{"week", "week", date(2026, 10, 15, 10), 0, 0, date(2026, 10, 12, 0), date(2026, 10, 19, 0)},
+		{"week on sunday", "week", date(2026, 10, 18, 23), 0, 0, date(2026, 10, 12, 0), date(2026, 10, 19, 0)},
+		{"month", "month", date(2026, 10, 15, 10), 0, 0, date(2026, 10, 1, 0), date(2026, 11, 1, 0)},
+		{"year", "year", date(2026, 10, 15, 10), 0, 0, date(2026, 1, 1, 0), date(2027, 1, 1, 0)},
+		{"previous day across year", "day", date(2026, 1, 1, 10), -1, 0, date(2025, 12, 31, 0), date(2026, 1, 1, 0)},
+		{"next week across year", "week", date(2026, 12, 30, 10), 1, 0, date(2027, 1, 4, 0), date(2027, 1, 11, 0)},
+		{"previous week across month", "week", date(2026, 10, 1, 10), -1, 0, date(2026, 9, 21, 0), date(2026, 9, 28, 0)},
+		{"next month from january 31", "month", date(2026, 1, 31, 10), 1, 0, date(2026, 2, 1, 0), date(2026, 3, 1, 0)},
+		{"previous month across year", "month", date(2026, 1, 15, 10), -1, 0, date(2025, 12, 1, 0), date(2026, 1, 1, 0)},
+		{"next month across year", "month", date(2026, 12, 31, 10), 1, 0, date(2027, 1, 1, 0), date(2027, 2, 1, 0)},
+		{"previous year", "year", date(2026, 6, 1, 10), -1, 0, date(2025, 1, 1, 0), date(2026, 1, 1, 0)},
+		{"day before day start", "day", date(2026, 10, 15, 3), 0, 4 * time.Hour, date(2026, 10, 14, 4), date(2026, 10, 15, 4)},
+		{"day after day start", "day", date(2026, 10, 15, 5), 0, 4 * time.Hour, date(2026, 10, 15, 4), date(2026, 10, 16, 4)},
+		{"month before day start", "month", date(2026, 11, 1, 3), 0, 4 * time.Hour, date(2026, 10, 1, 4), date(2026, 11, 1, 4)},
+		{"year before day start", "year", date(2027, 1, 1, 3), 0, 4 * time.Hour, date(2026, 1, 1, 4), date(2027, 1, 1, 4)},
+	}
+
+	for _, tt := range tests {
+		start, end, err := PeriodRange(tt.period, tt.ref, tt.offset, tt.dayStart)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("%s: got [%s, %s), want [%s, %s)", tt.name, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestPeriodRangeInvalid(t *testing.T) {
+	if _, _, err := PeriodRange("fortnight", time.Now(), 0, 0); err == nil {
+		t.Error("expected error for unknown period")
+	}
+}
+
+func TestPeriodRangeDST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Belgrade")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+
+	// clocks go back from 03:00 to 02:00 on 2026-10-25
+	ref := time.Date(2026, 10, 25, 12, 0, 0, 0, loc)
+
+	tests := []struct {
+		period   string
+		ref      time.Time
+		dayStart time.Duration
+		want     time.Duration
+	}{
+		{"day", ref, 0, 25 * time.Hour},
+		{"week", ref, 0, 7*24*time.Hour + time.Hour},
+		// with 04:00 day start the change belongs to logical day of 24th
+		{"day", ref, 4 * time.Hour, 24 * time.Hour},
+		{"day", time.Date(2026, 10, 25, 1, 0, 0, 0, loc), 4 * time.Hour, 25 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		start, end, err := PeriodRange(tt.period, tt.ref, 0, tt.dayStart)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.period, err)
+		}
+		if got := end.Sub(start); got != tt.want {
+			t.Errorf("%s with day start %s: length %s, want %s", tt.period, tt.dayStart, got, tt.want)
+		}
+		if start.Hour() != int(tt.dayStart/time.Hour) || end.Hour() != int(tt.dayStart/time.Hour) {
+			t.Errorf("%s: boundaries [%s, %s) are not at day start", tt.period, start, end)
+		}
+	}
+
+	// the day after the change is 24 hours again
+	start, end, err := PeriodRange("day", ref, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := end.Sub(start); got != 24*time.Hour {
+		t.Errorf("day after DST change: length %s, want 24h", got)
+	}
+}
+
+func TestLogicalDay(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+
+	tests := []struct {
+		t        time.Time
+		dayStart time.Duration
+		want     time.Time
+	}{
+		{time.Date(2026, 10, 15, 3, 0, 0, 0, loc), 0, time.Date(2026, 10, 15, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 10, 15, 3, 0, 0, 0, loc), 4 * time.Hour, time.Date(2026, 10, 14, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 10, 15, 4, 0, 0, 0, loc), 4 * time.Hour, time.Date(2026, 10, 15, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 1, 1, 1, 30, 0, 0, loc), 4 * time.Hour, time.Date(2025, 12, 31, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		if got := LogicalDay(tt.t, tt.dayStart); !got.Equal(tt.want) {
+			t.Errorf("LogicalDay(%s, %s) = %s, want %s", tt.t, tt.dayStart, got, tt.want)
+		}
+	}
+}
+
+func TestClipDuration(t *testing.T) {
+	loc := time.FixedZone("CEST", 2*60*60)
+	at := func(day, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, loc)
+	}
+	from, to := at(15, 4), at(16, 4)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       time.Duration
+	}{
+		{"inside", at(15, 9), at(15, 11), 2 * time.Hour},
+		{"started day before", at(14, 23), at(15, 6), 2 * time.Hour},
+		{"ends next day", at(15, 22), at(16, 7), 6 * time.Hour},
+		{"covers whole day", at(14, 12), at(16, 12), 24 * time.Hour},
+		{"before", at(14, 9), at(14, 11), 0},
+		{"after", at(16, 5), at(16, 6), 0},
+	}
+
+	for _, tt := range tests {
+		if got := ClipDuration(tt.start, tt.end, from, to); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
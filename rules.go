@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maps imported entry note to sheet, either by keyword (case insensitive
+// substring, e.g. "#acme") or by regular expression pattern
+type ImportRule struct {
+	Keyword string `json:"keyword,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Sheet   string `json:"sheet"`
+
+	re *regexp.Regexp
+}
+
+// loads rules from json file, missing file results in no rules
+func LoadImportRules(path string) ([]ImportRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read import rules: %w", err)
+	}
+
+	var rules []ImportRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse import rules %s: %w", path, err)
+	}
+
+	for i := range rules {
+		rule := &rules[i]
+
+		if rule.Sheet == "" {
+			return nil, fmt.Errorf("import rule %d: sheet is required", i+1)
+		}
+		if (rule.Keyword == "") == (rule.Pattern == "") {
+			return nil, fmt.Errorf("import rule %d: exactly one of keyword or pattern is required", i+1)
+		}
+
+		if rule.Pattern != "" {
+			rule.re, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("import rule %d: invalid pattern: %w", i+1, err)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// checks if rule matches note
+func (r *ImportRule) Match(note string) bool {
+	if r.re != nil {
+		return r.re.MatchString(note)
+	}
+	return strings.Contains(strings.ToLower(note), strings.ToLower(r.Keyword))
+}
+
+// human readable rule description used in import summary
+func (r *ImportRule) String() string {
+	if r.Pattern != "" {
+		return fmt.Sprintf("pattern /%s/ -> %s", r.Pattern, r.Sheet)
+	}
+	return fmt.Sprintf("keyword %q -> %s", r.Keyword, r.Sheet)
+}
+
+// returns index of first rule matching note, -1 if none matches
+func MatchImportRule(rules []ImportRule, note string) int {
+	for i := range rules {
+		if rules[i].Match(note) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// hashtag in note, only at start of note or after whitespace so urls
+	// with fragments are not treated as tags
+	hashtagRe = regexp.MustCompile(`(?:^|[\s(])#([\p{L}\p{N}_-]+)`)
+	tagNameRe = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+)
+
+// returns tags of entry from hashtags in note and explicitly given tags,
+// tags are lowercased and deduplicated keeping first occurrence order
+func EntryTags(note string, extra []string) ([]string, error) {
+	var tags []string
+	for _, match := range hashtagRe.FindAllStringSubmatch(note, -1) {
+		tags = append(tags, match[1])
+	}
+	tags = append(tags, extra...)
+
+	return NormalizeTags(tags)
+}
+
+// returns tags of entry after its note changed, hashtags of old note are
+// replaced by hashtags of new note and other tags (given by --tag) are kept
+func RetagNote(tags []string, oldNote string, newNote string) ([]string, error) {
+	oldTags, err := EntryTags(oldNote, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fromNote := make(map[string]bool)
+	for _, tag := range oldTags {
+		fromNote[tag] = true
+	}
+
+	var kept []string
+	for _, tag := range tags {
+		if !fromNote[tag] {
+			kept = append(kept, tag)
+		}
+	}
+
+	return EntryTags(newNote, kept)
+}
+
+// lowercases tags, strips leading '#' and removes duplicates
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" {
+			continue
+		}
+		if !tagNameRe.MatchString(tag) {
+			return nil, fmt.Errorf("Invalid tag: %s (only letters, digits, '_' and '-' are allowed)", tag)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized, nil
+}
+
+// checks if entry has all of given tags
+func (e *Entry) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, entryTag := range e.Tags {
+			if entryTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// keeps only entries having all of given tags, sheets left without
+// entries are dropped
+func FilterSheetsByTags(sheets []Sheet, tags []string) []Sheet {
+	if len(tags) == 0 {
+		return sheets
+	}
+
+	var filtered []Sheet
+	for _, sheet := range sheets {
+		var entries []Entry
+		for _, entry := range sheet.Entries {
+			if entry.HasTags(tags) {
+				entries = append(entries, entry)
+			}
+		}
+
+		if len(entries) > 0 {
+			sheet.Entries = entries
+			filtered = append(filtered, sheet)
+		}
+	}
+
+	return filtered
+}
+
+// sums duration of entries per tag, entry with multiple tags counts
+// towards each of them, sorted by longest total
+func TagTotals(sheets []Sheet) []TagTotal {
+	var totals []TagTotal
+	index := make(map[string]int)
+
+	for _, sheet := range sheets {
+		for _, entry := range sheet.Entries {
+			for _, tag := range entry.Tags {
+				i, exists := index[tag]
+				if !exists {
+					i = len(totals)
+					index[tag] = i
+					totals = append(totals, TagTotal{Tag: tag})
+				}
+
+				totals[i].Entries++
+				totals[i].Total += entry.EndTime.Sub(entry.StartTime)
+			}
+		}
+	}
+
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return totals[i].Tag < totals[j].Tag
+	})
+
+	return totals
+}
+
+// splits tags aggregated by GROUP_CONCAT
+func splitTags(value string) []string {
+	if value == "" {
+		return nil
+	}
+	tags := strings.Split(value, ",")
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// "2h ago", "15 minutes ago"
+	agoRegex = regexp.MustCompile(`^(\d+)\s*([a-z]+)\s+ago$`)
+	// "in 2h", "in 15 minutes"
+	inRegex = regexp.MustCompile(`^in\s+(\d+)\s*([a-z]+)$`)
+	// "14:00", "9:15:30", "2pm", "2:30pm", "9am"
+	clockRegex = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?\s*(am|pm)?$`)
+
+	// absolute layouts carrying their own zone ("Z" or "+02:00")
+	zonedLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02 15:04Z07:00",
+	}
+
+	// absolute layouts in order of precedence, parsed in parser location
+	absoluteLayouts = []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	weekdays = map[string]time.Weekday{
+		"sunday":    time.Sunday,
+		"monday":    time.Monday,
+		"tuesday":   time.Tuesday,
+		"wednesday": time.Wednesday,
+		"thursday":  time.Thursday,
+		"friday":    time.Friday,
+		"saturday":  time.Saturday,
+	}
+)
+
+// parses time expressions used by every command, relative expressions
+// are resolved against Now so it can be replaced with fixed clock
+type TimeParser struct {
+	Now      func() time.Time
+	Location *time.Location
+}
+
+func NewTimeParser() *TimeParser {
+	return &TimeParser{
+		Now:      time.Now,
+		Location: time.Local,
+	}
+}
+
+// returns current time in parser location
+func (p *TimeParser) Current() time.Time {
+	return p.Now().In(p.Location)
+}
+
+// parses time expression, supported forms are:
+//   - ISO 8601 ("2026-10-15T17:30:00+02:00", "2026-10-15 17:30", "2026-10-15")
+//   - clock time for today ("09:15", "2pm", "noon", "midnight")
+//   - day with optional time ("yesterday 14:00", "last monday", "friday at noon")
+//   - relative ("now", "2h ago", "15 minutes ago", "in 1h", "-10m", "+1h30m")
+func (p *TimeParser) Parse(value string) (time.Time, error) {
+	expr := strings.ToLower(strings.Join(strings.Fields(value), " "))
+	if expr == "" {
+		return time.Time{}, fmt.Errorf("empty time expression")
+	}
+
+	now := p.Current()
+
+	if expr == "now" {
+		return now, nil
+	}
+
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(expr)); err == nil {
+			return t.In(p.Location), nil
+		}
+	}
+	for _, layout := range absoluteLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(expr), p.Location); err == nil {
+			return t, nil
+		}
+	}
+
+	// go durations with explicit sign
+	if strings.HasPrefix(expr, "-") || strings.HasPrefix(expr, "+") {
+		d, err := time.ParseDuration(strings.ReplaceAll(expr, " ", ""))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time offset: %s", value)
+		}
+		return now.Add(d), nil
+	}
+
+	if m := agoRegex.FindStringSubmatch(expr); m != nil {
+		return p.shift(now, m[1], m[2], -1, value)
+	}
+	if m := inRegex.FindStringSubmatch(expr); m != nil {
+		return p.shift(now, m[1], m[2], 1, value)
+	}
+
+	// day part followed by optional clock part
+	day, rest, ok := p.parseDay(now, expr)
+	if !ok {
+		// clock only, resolve against today
+		day = startOfDay(now)
+		rest = expr
+	}
+
+	rest = strings.TrimPrefix(strings.TrimSpace(rest), "at ")
+	if rest == "" {
+		return day, nil
+	}
+
+	hour, minute, second, ok := parseClock(rest)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time: %s", value)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, p.Location), nil
+}
+
+// parses end of entry starting at start, unsigned or "+" durations ("2h30m",
+// "+45m") are relative to start, anything else ("-10m" included) is time
+// expression relative to now
+func (p *TimeParser) ParseEnd(start time.Time, value string) (time.Time, error) {
+	if !strings.HasPrefix(value, "-") {
+		if d, err := time.ParseDuration(strings.TrimPrefix(value, "+")); err == nil {
+			return start.Add(d), nil
+		}
+	}
+
+	return p.Parse(value)
+}
+
+// moves now by n units in given direction
+func (p *TimeParser) shift(now time.Time, n string, unit string, direction int, value string) (time.Time, error) {
+	amount, err := strconv.Atoi(n)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %s", value)
+	}
+	amount *= direction
+
+	switch unit {
+	case "s", "sec", "secs", "second", "seconds":
+		return now.Add(time.Duration(amount) * time.Second), nil
+	case "m", "min", "mins", "minute", "minutes":
+		return now.Add(time.Duration(amount) * time.Minute), nil
+	case "h", "hr", "hrs", "hour", "hours":
+		return now.Add(time.Duration(amount) * time.Hour), nil
+	case "d", "day", "days":
+		return now.AddDate(0, 0, amount), nil
+	case "w", "week", "weeks":
+		return now.AddDate(0, 0, 7*amount), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time unit '%s' in: %s", unit, value)
+}
+
+// parses leading day word of expression, returns midnight of that day and remaining expression
+func (p *TimeParser) parseDay(now time.Time, expr string) (time.Time, string, bool) {
+	word, rest, _ := strings.Cut(expr, " ")
+	today := startOfDay(now)
+
+	switch word {
+	case "today":
+		return today, rest, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), rest, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), rest, true
+	case "last", "next":
+		name, after, _ := strings.Cut(rest, " ")
+		weekday, ok := weekdays[name]
+		if !ok {
+			return time.Time{}, "", false
+		}
+		diff := int(weekday - today.Weekday())
+		if word == "last" {
+			// strictly before today
+			if diff >= 0 {
+				diff -= 7
+			}
+		} else if diff <= 0 {
+			// strictly after today
+			diff += 7
+		}
+		return today.AddDate(0, 0, diff), after, true
+	}
+
+	// bare weekday means most recent one, today included
+	if weekday, ok := weekdays[word]; ok {
+		diff := int(weekday - today.Weekday())
+		if diff > 0 {
+			diff -= 7
+		}
+		return today.AddDate(0, 0, diff), rest, true
+	}
+
+	// explicit date followed by time in "2026-10-15 at 9am" form
+	if t, err := time.ParseInLocation("2006-01-02", word, p.Location); err == nil {
+		return t, rest, true
+	}
+
+	return time.Time{}, "", false
+}
+
+// parses clock part of expression into hour, minute and second
+func parseClock(value string) (int, int, int, bool) {
+	switch value {
+	case "noon":
+		return 12, 0, 0, true
+	case "midnight":
+		return 0, 0, 0, true
+	}
+
+	m := clockRegex.FindStringSubmatch(value)
+	if m == nil {
+		return 0, 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	second, _ := strconv.Atoi(m[3])
+
+	switch m[4] {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, 0, false
+		}
+		if hour != 12 {
+			hour += 12
+		}
+	default:
+		// bare number without minutes is ambiguous ("15" could be a day)
+		if m[2] == "" {
+			return 0, 0, 0, false
+		}
+	}
+
+	if hour > 23 || minute > 59 || second > 59 {
+		return 0, 0, 0, false
+	}
+
+	return hour, minute, second, true
+}
+
+// returns midnight of the day in time location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
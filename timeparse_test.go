@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// parser with fixed clock on given wall time in UTC+2
+func fixedTimeParser(year int, month time.Month, day, hour, minute int) *TimeParser {
+	loc := time.FixedZone("CEST", 2*60*60)
+	now := time.Date(year, month, day, hour, minute, 0, 0, loc)
+
+	return &TimeParser{
+		Now:      func() time.Time { return now },
+		Location: loc,
+	}
+}
+
+func TestTimeParserParse(t *testing.T) {
+	// Thursday
+	parser := fixedTimeParser(2026, time.October, 15, 10, 30)
+	loc := parser.Location
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"now", time.Date(2026, 10, 15, 10, 30, 0, 0, loc)},
+		{"yesterday 14:00", time.Date(2026, 10, 14, 14, 0, 0, 0, loc)},
+		{"yesterday", time.Date(2026, 10, 14, 0, 0, 0, 0, loc)},
+		{"tomorrow at 9am", time.Date(2026, 10, 16, 9, 0, 0, 0, loc)},
+		{"last monday", time.Date(2026, 10, 12, 0, 0, 0, 0, loc)},
+		{"last thursday", time.Date(2026, 10, 8, 0, 0, 0, 0, loc)},
+		{"next thursday", time.Date(2026, 10, 22, 0, 0, 0, 0, loc)},
+		{"thursday", time.Date(2026, 10, 15, 0, 0, 0, 0, loc)},
+		{"friday at noon", time.Date(2026, 10, 9, 12, 0, 0, 0, loc)},
+		{"2h ago", time.Date(2026, 10, 15, 8, 30, 0, 0, loc)},
+		{"15 minutes ago", time.Date(2026, 10, 15, 10, 15, 0, 0, loc)},
+		{"in 1h", time.Date(2026, 10, 15, 11, 30, 0, 0, loc)},
+		{"-10m", time.Date(2026, 10, 15, 10, 20, 0, 0, loc)},
+		{"+1h30m", time.Date(2026, 10, 15, 12, 0, 0, 0, loc)},
+		{"noon", time.Date(2026, 10, 15, 12, 0, 0, 0, loc)},
+		{"midnight", time.Date(2026, 10, 15, 0, 0, 0, 0, loc)},
+		{"12am", time.Date(2026, 10, 15, 0, 0, 0, 0, loc)},
+		{"12pm", time.Date(2026, 10, 15, 12, 0, 0, 0, loc)},
+		{"2pm", time.Date(2026, 10, 15, 14, 0, 0, 0, loc)},
+		{"2:30 PM", time.Date(2026, 10, 15, 14, 30, 0, 0, loc)},
+		{"09:15", time.Date(2026, 10, 15, 9, 15, 0, 0, loc)},
+		{"9:15:30", time.Date(2026, 10, 15, 9, 15, 30, 0, loc)},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, loc)},
+		{"2026-10-01 17:30", time.Date(2026, 10, 1, 17, 30, 0, 0, loc)},
+		{"2026-10-01T17:30:15", time.Date(2026, 10, 1, 17, 30, 15, 0, loc)},
+		{"2026-10-01 at 9am", time.Date(2026, 10, 1, 9, 0, 0, 0, loc)},
+		{"2026-10-15T17:30:00+02:00", time.Date(2026, 10, 15, 17, 30, 0, 0, loc)},
+		{"2026-10-15T17:30:00Z", time.Date(2026, 10, 15, 19, 30, 0, 0, loc)},
+		{"2026-10-15T17:30+02:00", time.Date(2026, 10, 15, 17, 30, 0, 0, loc)},
+		{"2026-10-15T17:30Z", time.Date(2026, 10, 15, 19, 30, 0, 0, loc)},
+		{"2026-10-15 17:30-01:00", time.Date(2026, 10, 15, 20, 30, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		got, err := parser.Parse(tt.value)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTimeParserLastWeekdayOnSameWeekday(t *testing.T) {
+	// Monday
+	parser := fixedTimeParser(2026, time.October, 12, 10, 30)
+	loc := parser.Location
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"last monday", time.Date(2026, 10, 5, 0, 0, 0, 0, loc)},
+		{"monday", time.Date(2026, 10, 12, 0, 0, 0, 0, loc)},
+		{"next monday", time.Date(2026, 10, 19, 0, 0, 0, 0, loc)},
+		{"last sunday", time.Date(2026, 10, 11, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		got, err := parser.Parse(tt.value)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTimeParserParseEnd(t *testing.T) {
+	parser := fixedTimeParser(2026, time.October, 15, 10, 30)
+	loc := parser.Location
+	start := time.Date(2026, 10, 15, 9, 0, 0, 0, loc)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		// durations from start
+		{"2h30m", time.Date(2026, 10, 15, 11, 30, 0, 0, loc)},
+		{"+45m", time.Date(2026, 10, 15, 9, 45, 0, 0, loc)},
+		{"90s", time.Date(2026, 10, 15, 9, 1, 30, 0, loc)},
+		// times, signed offsets are relative to now
+		{"-10m", time.Date(2026, 10, 15, 10, 20, 0, 0, loc)},
+		{"11:15", time.Date(2026, 10, 15, 11, 15, 0, 0, loc)},
+		{"now", time.Date(2026, 10, 15, 10, 30, 0, 0, loc)},
+		{"15 minutes ago", time.Date(2026, 10, 15, 10, 15, 0, 0, loc)},
+		{"2026-10-16 08:00", time.Date(2026, 10, 16, 8, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		got, err := parser.ParseEnd(start, tt.value)
+		if err != nil {
+			t.Errorf("ParseEnd(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseEnd(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	if got, err := parser.ParseEnd(start, "soon"); err == nil {
+		t.Errorf("ParseEnd(%q) = %s, want error", "soon", got)
+	}
+}
+
+func TestTimeParserInvalid(t *testing.T) {
+	parser := fixedTimeParser(2026, time.October, 15, 10, 30)
+
+	values := []string{
+		"",
+		"   ",
+		"soon",
+		"15",
+		"25:00",
+		"13pm",
+		"0am",
+		"12:60",
+		"2 fortnights ago",
+		"-10x",
+		"last someday",
+		"yesterday at teatime",
+		"2026-13-01",
+	}
+
+	for _, value := range values {
+		if got, err := parser.Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", value, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// timestamps are cast to text, so they are parsed in local time
+	// instead of being interpreted as UTC by the driver
+	getTimetrapEntriesSQL = `
+  SELECT id, sheet, CAST(start AS TEXT), CAST(end AS TEXT), COALESCE(note, '')
+  FROM entries
+  ORDER BY start
+  `
+	getTimetrapCurrentSheetSQL = `SELECT value FROM meta WHERE key = 'current_sheet'`
+)
+
+// timetrap stores local time without zone, fraction of second is optional
+var timetrapTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999",
+}
+
+type TimetrapEntry struct {
+	ID        int64
+	Sheet     string
+	StartTime time.Time
+	EndTime   sql.NullTime
+	Note      string
+}
+
+// reads all entries and current sheet from timetrap database
+func ReadTimetrapDatabase(path string) ([]TimetrapEntry, string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, "", fmt.Errorf("failed to open timetrap database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open timetrap database: %w", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(getTimetrapEntriesSQL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read timetrap entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []TimetrapEntry
+	for rows.Next() {
+		var entry TimetrapEntry
+		var start string
+		var end sql.NullString
+
+		if err := rows.Scan(&entry.ID, &entry.Sheet, &start, &end, &entry.Note); err != nil {
+			return nil, "", err
+		}
+
+		entry.StartTime, err = parseTimetrapTime(start)
+		if err != nil {
+			return nil, "", fmt.Errorf("entry %d: %w", entry.ID, err)
+		}
+
+		if end.Valid && end.String != "" {
+			endTime, err := parseTimetrapTime(end.String)
+			if err != nil {
+				return nil, "", fmt.Errorf("entry %d: %w", entry.ID, err)
+			}
+			entry.EndTime = sql.NullTime{Time: endTime, Valid: true}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// meta table holds current sheet, missing value is not an error
+	var currentSheet string
+	err = db.QueryRow(getTimetrapCurrentSheetSQL).Scan(&currentSheet)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, "", fmt.Errorf("failed to read timetrap current sheet: %w", err)
+	}
+
+	return entries, currentSheet, nil
+}
+
+func parseTimetrapTime(value string) (time.Time, error) {
+	for _, layout := range timetrapTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid timetrap timestamp: %s", value)
+}
@@ -1,12 +1,24 @@
 package main
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type Sheet struct {
-	ID      int64
-	Name    string
-	Active  bool
-	Entries []Entry
+	ID       int64
+	Name     string
+	Active   bool
+	Archived bool
+	Entries  []Entry
+}
+
+// sheet with summary of its entries, used by sheet list
+type SheetStats struct {
+	Sheet
+	EntryCount int
+	Total      time.Duration // total of finished entries
+	LastUsed   time.Time     // start of last entry, zero if sheet has no entries
 }
 
 type Entry struct {
@@ -16,8 +28,95 @@ type Entry struct {
 	EndTime   time.Time
 	Note      string
 	CreatedAt time.Time
+	Tags      []string
+}
+
+// total duration of entries with tag
+type TagTotal struct {
+	Tag     string
+	Entries int
+	Total   time.Duration
+}
+
+// entry fetched from external source, source and source id identify it
+// so importing it again is a no-op
+type ImportedEntry struct {
+	Sheet     string
+	StartTime time.Time
+	EndTime   sql.NullTime
+	Note      string
+	Source    string
+	SourceID  int64
 }
 
 type DisplayOptions struct {
-	Type string // "day", "week", "month", "year"
+	Type   string   // "day", "week", "month", "year"
+	Date   string   // reference date of period, defaults to now
+	Offset int      // number of periods to move from reference date, -1 is previous period
+	Start  string   // explicit range start, overrides period
+	End    string   // explicit range end, defaults to now
+	Format string   // "table", "json", "csv", "tsv"
+	Tags   []string // only entries having all of these tags
+}
+
+type ExportOptions struct {
+	DisplayOptions
+	Output        string // export file path, stdout if empty
+	AccountPrefix string // prefix of timeclock account names (e.g. "work:")
+}
+
+type TelegramImportOptions struct {
+	Since          string // only entries started after this time are imported
+	RulesPath      string // import rules file, config value is used if empty
+	DefaultSheet   string // sheet for entries not matching any rule
+	NonInteractive bool   // skip preview, unmatched and running entries are not imported
+}
+
+// column mapping of csv import, columns are header names or 1-based indexes
+type CSVImportOptions struct {
+	SheetColumn    string
+	StartColumn    string
+	EndColumn      string
+	DurationColumn string
+	NoteColumn     string
+	Sheet          string // sheet used when there is no sheet column
+	DateFormat     string // go time layout, time expressions are parsed if empty
+	Delimiter      string
+	DryRun         bool
+}
+
+// note used on sheet, offered by resume picker
+type RecentNote struct {
+	Sheet string
+	Note  string
+	// latest entry with note
+	EntryID int64
+}
+
+// data available to status command template
+type StatusInfo struct {
+	Sheet          string    // active sheet, empty if none
+	Running        bool      // whether any entry is running
+	RunningSheet   string    // sheet of running entry
+	Note           string    // note of running entry
+	StartTime      time.Time // start of running entry
+	Elapsed        string    // elapsed time of running entry (H:MM:SS)
+	ElapsedSeconds int64
+	Today          string // today's total of active sheet (H:MM:SS), running entry included
+	TodaySeconds   int64
+}
+
+// changes applied to an entry by edit command, nil fields are left untouched
+type EditOptions struct {
+	Start *string
+	End   *string
+	Note  *string
+	Sheet *string
+}
+
+// schema migration, AppliedAt is zero while migration is pending
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
 }